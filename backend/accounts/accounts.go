@@ -0,0 +1,363 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+/*
+Usuarios y grupos de una partición, guardados en /users.txt.
+
+Versión 1 (discos creados antes del hash de contraseñas), contraseñas en texto plano:
+	1,G,root
+	1,U,root,123                 línea antigua de root, sin grupo
+	2,U,grupo,usuario,contraseña
+Versión 2, la primera línea es el encabezado y las contraseñas se guardan con utils.HashPassword:
+	#v2
+	1,G,root
+	1,U,root,root,pbkdf2-sha256$...
+	2,U,grupo,usuario,pbkdf2-sha256$...,otro;grupos   grupos adicionales (opcional)
+
+Un ID 0 marca un registro eliminado; los registros eliminados se conservan, pero ninguna
+búsqueda los devuelve. El siguiente ID es la cantidad de registros de ese tipo, incluidos
+los eliminados, más uno: así un UID o GID eliminado, que los inodos todavía pueden tener,
+no se asigna a otro usuario o grupo. Un archivo v1 se migra a v2 (se calcula el hash de
+todas sus contraseñas) la próxima vez que se guarda.
+*/
+
+// header es la primera línea de un users.txt versión 2
+const header = "#v2"
+
+// Group es un grupo de users.txt
+type Group struct {
+	ID   int32
+	Name string
+}
+
+// Deleted indica si el grupo fue eliminado
+func (g *Group) Deleted() bool {
+	return g.ID == 0
+}
+
+func (g *Group) String() string {
+	return fmt.Sprintf("%d,G,%s", g.ID, g.Name)
+}
+
+// User es un usuario de users.txt
+type User struct {
+	ID       int32
+	Group    string // Grupo principal
+	Name     string
+	Password string   // Hash en v2, texto plano en v1
+	Groups   []string // Grupos adicionales
+}
+
+// Deleted indica si el usuario fue eliminado
+func (u *User) Deleted() bool {
+	return u.ID == 0
+}
+
+// CheckPassword indica si password es la contraseña del usuario
+func (u *User) CheckPassword(password string) bool {
+	if utils.IsPasswordHash(u.Password) {
+		return utils.CheckPassword(u.Password, password)
+	}
+	return u.Password == password
+}
+
+func (u *User) String() string {
+	line := fmt.Sprintf("%d,U,%s,%s,%s", u.ID, u.Group, u.Name, u.Password)
+	if len(u.Groups) > 0 {
+		line += "," + strings.Join(u.Groups, ";")
+	}
+	return line
+}
+
+// File es el contenido de users.txt. Los registros (*Group o *User) quedan en el orden del archivo.
+type File struct {
+	Version int
+	records []fmt.Stringer
+}
+
+// New devuelve el users.txt inicial de un sistema de archivos: grupo root y usuario root con contraseña 123
+func New() (*File, error) {
+	file := &File{Version: 1, records: []fmt.Stringer{
+		&Group{ID: 1, Name: "root"},
+		&User{ID: 1, Group: "root", Name: "root", Password: "123"},
+	}}
+	if err := file.Migrate(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Parse interpreta el contenido de users.txt en cualquiera de sus versiones
+func Parse(content string) (*File, error) {
+	file := &File{Version: 1}
+	for n, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == header && n == 0 {
+			file.Version = 2
+			continue
+		}
+
+		parts := strings.Split(line, ",")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) < 3 {
+			return nil, fmt.Errorf("línea %d de users.txt inválida: %s", n+1, line)
+		}
+		switch {
+		case parts[1] == "G" && len(parts) == 3:
+			file.records = append(file.records, &Group{ID: int32(id), Name: parts[2]})
+		case parts[1] == "U" && (len(parts) == 5 || len(parts) == 6 && file.Version == 2):
+			user := &User{ID: int32(id), Group: parts[2], Name: parts[3], Password: parts[4]}
+			if len(parts) == 6 {
+				for _, group := range strings.Split(parts[5], ";") {
+					if group != "" {
+						user.Groups = append(user.Groups, group)
+					}
+				}
+			}
+			file.records = append(file.records, user)
+		case parts[1] == "U" && len(parts) == 4 && file.Version == 1:
+			// Línea antigua de root: UID,U,usuario,contraseña
+			file.records = append(file.records, &User{ID: int32(id), Group: "root", Name: parts[2], Password: parts[3]})
+		default:
+			return nil, fmt.Errorf("línea %d de users.txt inválida: %s", n+1, line)
+		}
+	}
+	return file, nil
+}
+
+// String devuelve el contenido de users.txt en formato v2
+func (f *File) String() string {
+	var content strings.Builder
+	content.WriteString(header + "\n")
+	for _, record := range f.records {
+		content.WriteString(record.String() + "\n")
+	}
+	return content.String()
+}
+
+// Migrate calcula el hash de las contraseñas en texto plano y pasa el archivo a v2
+func (f *File) Migrate() error {
+	for _, user := range f.Users() {
+		if utils.IsPasswordHash(user.Password) {
+			continue
+		}
+		hash, err := utils.HashPassword(user.Password)
+		if err != nil {
+			return err
+		}
+		user.Password = hash
+	}
+	f.Version = 2
+	return nil
+}
+
+// Groups devuelve todos los grupos del archivo, incluidos los eliminados
+func (f *File) Groups() []*Group {
+	var groups []*Group
+	for _, record := range f.records {
+		if group, ok := record.(*Group); ok {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// Users devuelve todos los usuarios del archivo, incluidos los eliminados
+func (f *File) Users() []*User {
+	var users []*User
+	for _, record := range f.records {
+		if user, ok := record.(*User); ok {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// Group devuelve el grupo activo con el nombre indicado, o nil
+func (f *File) Group(name string) *Group {
+	for _, group := range f.Groups() {
+		if group.Name == name && !group.Deleted() {
+			return group
+		}
+	}
+	return nil
+}
+
+// GroupByID devuelve el grupo activo con el ID indicado, o nil
+func (f *File) GroupByID(id int32) *Group {
+	for _, group := range f.Groups() {
+		if group.ID == id && !group.Deleted() {
+			return group
+		}
+	}
+	return nil
+}
+
+// User devuelve el usuario activo con el nombre indicado, o nil
+func (f *File) User(name string) *User {
+	for _, user := range f.Users() {
+		if user.Name == name && !user.Deleted() {
+			return user
+		}
+	}
+	return nil
+}
+
+// UserByID devuelve el usuario activo con el ID indicado, o nil
+func (f *File) UserByID(id int32) *User {
+	for _, user := range f.Users() {
+		if user.ID == id && !user.Deleted() {
+			return user
+		}
+	}
+	return nil
+}
+
+// GroupIDs devuelve el GID del grupo principal del usuario y los de sus grupos adicionales.
+// Los grupos adicionales eliminados se ignoran; el grupo principal debe existir.
+func (f *File) GroupIDs(user *User) (int32, []int32, error) {
+	primary := f.Group(user.Group)
+	if primary == nil {
+		return -1, nil, fmt.Errorf("el grupo %s del usuario %s no existe o está eliminado", user.Group, user.Name)
+	}
+	var extra []int32
+	for _, name := range user.Groups {
+		if group := f.Group(name); group != nil && group.ID != primary.ID {
+			extra = append(extra, group.ID)
+		}
+	}
+	return primary.ID, extra, nil
+}
+
+// AddGroup agrega un grupo con el siguiente GID libre
+func (f *File) AddGroup(name string) (*Group, error) {
+	if f.Group(name) != nil {
+		return nil, errors.New("el grupo ya existe")
+	}
+	groups := f.Groups()
+	group := &Group{Name: name, ID: int32(len(groups)) + 1}
+	for _, g := range groups {
+		group.ID = max(group.ID, g.ID+1)
+	}
+	f.records = append(f.records, group)
+	return group, nil
+}
+
+// RemoveGroup elimina el grupo y lo quita de los grupos adicionales de sus miembros.
+// El grupo root y los grupos principales de usuarios activos no se pueden eliminar,
+// porque esos usuarios ya no podrían iniciar sesión.
+func (f *File) RemoveGroup(name string) error {
+	group := f.Group(name)
+	if group == nil {
+		return errors.New("el grupo no existe o ya está eliminado")
+	}
+	if name == "root" {
+		return errors.New("el grupo root no se puede eliminar")
+	}
+	for _, user := range f.Users() {
+		if user.Group == name && !user.Deleted() {
+			return fmt.Errorf("el grupo %s es el grupo principal del usuario %s", name, user.Name)
+		}
+	}
+	group.ID = 0
+	for _, user := range f.Users() {
+		user.Groups = slices.DeleteFunc(user.Groups, func(g string) bool { return g == name })
+	}
+	return nil
+}
+
+// AddUser agrega un usuario del grupo indicado con el siguiente UID libre y el hash de su contraseña
+func (f *File) AddUser(name, password, group string) (*User, error) {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular el hash de la contraseña: %v", err)
+	}
+	return f.AddUserWithHash(name, hash, group)
+}
+
+// AddUserWithHash agrega un usuario con un hash de contraseña ya calculado (ver utils.HashPassword)
+func (f *File) AddUserWithHash(name, hash, group string) (*User, error) {
+	if !utils.IsPasswordHash(hash) || strings.ContainsAny(hash, ",;\n") {
+		return nil, errors.New("el hash de la contraseña no es válido")
+	}
+	if f.User(name) != nil {
+		return nil, errors.New("el usuario ya existe")
+	}
+	if f.Group(group) == nil {
+		return nil, errors.New("el grupo especificado no existe o está eliminado")
+	}
+	users := f.Users()
+	user := &User{Group: group, Name: name, Password: hash, ID: int32(len(users)) + 1}
+	for _, u := range users {
+		user.ID = max(user.ID, u.ID+1)
+	}
+	f.records = append(f.records, user)
+	return user, nil
+}
+
+// RemoveUser elimina el usuario
+func (f *File) RemoveUser(name string) error {
+	user := f.User(name)
+	if user == nil {
+		return errors.New("el usuario no existe o ya está eliminado")
+	}
+	user.ID = 0
+	return nil
+}
+
+// SetGroup cambia el grupo principal del usuario
+func (f *File) SetGroup(name, group string) error {
+	user := f.User(name)
+	if user == nil {
+		return errors.New("el usuario no existe o está eliminado")
+	}
+	if f.Group(group) == nil {
+		return errors.New("el grupo no existe o está eliminado")
+	}
+	user.Group = group
+	return nil
+}
+
+// AddToGroup agrega group a los grupos adicionales del usuario
+func (f *File) AddToGroup(name, group string) error {
+	user := f.User(name)
+	if user == nil {
+		return errors.New("el usuario no existe o está eliminado")
+	}
+	if f.Group(group) == nil {
+		return fmt.Errorf("el grupo %s no existe o está eliminado", group)
+	}
+	if group == user.Group {
+		return fmt.Errorf("el grupo %s ya es el grupo principal de %s", group, user.Name)
+	}
+	if !slices.Contains(user.Groups, group) {
+		user.Groups = append(user.Groups, group)
+	}
+	return nil
+}
+
+// RemoveFromGroup quita group de los grupos adicionales del usuario
+func (f *File) RemoveFromGroup(name, group string) error {
+	user := f.User(name)
+	if user == nil {
+		return errors.New("el usuario no existe o está eliminado")
+	}
+	index := slices.Index(user.Groups, group)
+	if index < 0 {
+		return fmt.Errorf("el usuario %s no pertenece al grupo adicional %s", user.Name, group)
+	}
+	user.Groups = slices.Delete(user.Groups, index, index+1)
+	return nil
+}
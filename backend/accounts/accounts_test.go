@@ -0,0 +1,248 @@
+package accounts
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const sampleV2 = `#v2
+1,G,root
+1,U,root,root,pbkdf2-sha256$1$c2FsdA$aGFzaA
+2,G,devs
+0,G,viejo
+2,U,devs,ana,pbkdf2-sha256$1$c2FsdA$aGFzaA,root;ops
+0,U,devs,bob,pbkdf2-sha256$1$c2FsdA$aGFzaA
+3,G,ops
+`
+
+func TestParseV2RoundTrip(t *testing.T) {
+	file, err := Parse(sampleV2)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if file.Version != 2 {
+		t.Errorf("Version = %d, se esperaba 2", file.Version)
+	}
+	if len(file.Groups()) != 4 || len(file.Users()) != 3 {
+		t.Errorf("se leyeron %d grupos y %d usuarios, se esperaban 4 y 3", len(file.Groups()), len(file.Users()))
+	}
+	if got := file.String(); got != sampleV2 {
+		t.Errorf("String() no conserva el archivo:\n%s\nse esperaba:\n%s", got, sampleV2)
+	}
+
+	ana := file.User("ana")
+	if ana == nil || ana.ID != 2 || ana.Group != "devs" || !slices.Equal(ana.Groups, []string{"root", "ops"}) {
+		t.Errorf("User(ana) = %+v", ana)
+	}
+}
+
+func TestParseV1(t *testing.T) {
+	file, err := Parse("1,G,root\n1,U,root,123\n2,G,devs\n2,U,devs,ana,secreto\n")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if file.Version != 1 {
+		t.Errorf("Version = %d, se esperaba 1", file.Version)
+	}
+	root := file.User("root")
+	if root == nil || root.Group != "root" || !root.CheckPassword("123") {
+		t.Errorf("la línea antigua de root no se interpretó: %+v", root)
+	}
+	if ana := file.User("ana"); ana == nil || !ana.CheckPassword("secreto") || ana.CheckPassword("otra") {
+		t.Errorf("contraseña en texto plano de ana mal verificada: %+v", ana)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, content := range []string{
+		"x,G,root\n",
+		"1,G\n",
+		"1,G,root,extra\n",
+		"1,X,root\n",
+		"#v2\n1,U,root,123\n",                // La línea antigua de root solo existe en v1
+		"1,G,root\n1,U,root,root,h,grupos\n", // Los grupos adicionales solo existen en v2
+		"1,G,root\n#v2\n",                    // El encabezado debe ser la primera línea
+	} {
+		if _, err := Parse(content); err == nil {
+			t.Errorf("Parse(%q) no devolvió error", content)
+		}
+	}
+}
+
+func TestLookups(t *testing.T) {
+	file, err := Parse(sampleV2)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if g := file.Group("ops"); g == nil || g.ID != 3 {
+		t.Errorf("Group(ops) = %+v", g)
+	}
+	if g := file.GroupByID(2); g == nil || g.Name != "devs" {
+		t.Errorf("GroupByID(2) = %+v", g)
+	}
+	if u := file.UserByID(1); u == nil || u.Name != "root" {
+		t.Errorf("UserByID(1) = %+v", u)
+	}
+
+	// Los registros eliminados no se encuentran ni por nombre ni por ID
+	if g := file.Group("viejo"); g != nil {
+		t.Errorf("Group(viejo) devolvió un grupo eliminado: %+v", g)
+	}
+	if u := file.User("bob"); u != nil {
+		t.Errorf("User(bob) devolvió un usuario eliminado: %+v", u)
+	}
+	if u := file.UserByID(0); u != nil {
+		t.Errorf("UserByID(0) = %+v", u)
+	}
+	if g := file.Group("nadie"); g != nil {
+		t.Errorf("Group(nadie) = %+v", g)
+	}
+}
+
+func TestGroupIDs(t *testing.T) {
+	file, err := Parse(sampleV2)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	gid, extra, err := file.GroupIDs(file.User("ana"))
+	if err != nil {
+		t.Fatalf("GroupIDs: %v", err)
+	}
+	if gid != 2 || !slices.Equal(extra, []int32{1, 3}) {
+		t.Errorf("GroupIDs(ana) = %d, %v; se esperaba 2, [1 3]", gid, extra)
+	}
+
+	// Un users.txt anterior a la validación de rmgrp puede tener el grupo principal eliminado
+	file, err = Parse(strings.Replace(sampleV2, "2,G,devs", "0,G,devs", 1))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, _, err := file.GroupIDs(file.User("ana")); err == nil {
+		t.Error("GroupIDs no falló con el grupo principal eliminado")
+	}
+}
+
+func TestAddAndRemove(t *testing.T) {
+	file, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	devs, err := file.AddGroup("devs")
+	if err != nil || devs.ID != 2 {
+		t.Fatalf("AddGroup(devs) = %+v, %v", devs, err)
+	}
+	if _, err := file.AddGroup("devs"); err == nil {
+		t.Error("AddGroup aceptó un grupo repetido")
+	}
+
+	ana, err := file.AddUser("ana", "secreto", "devs")
+	if err != nil || ana.ID != 2 {
+		t.Fatalf("AddUser(ana) = %+v, %v", ana, err)
+	}
+	if strings.Contains(ana.Password, "secreto") || !ana.CheckPassword("secreto") {
+		t.Errorf("la contraseña de ana no se guardó como hash: %s", ana.Password)
+	}
+	if _, err := file.AddUser("ana", "x", "devs"); err == nil {
+		t.Error("AddUser aceptó un usuario repetido")
+	}
+	if _, err := file.AddUser("bob", "x", "nadie"); err == nil {
+		t.Error("AddUser aceptó un grupo inexistente")
+	}
+
+	// recovery crea los usuarios con el hash guardado en el journal
+	bob, err := file.AddUserWithHash("bob", ana.Password, "devs")
+	if err != nil || bob.Password != ana.Password || !bob.CheckPassword("secreto") {
+		t.Fatalf("AddUserWithHash(bob) = %+v, %v", bob, err)
+	}
+	if _, err := file.AddUserWithHash("eva", "secreto", "devs"); err == nil {
+		t.Error("AddUserWithHash aceptó una contraseña en texto plano")
+	}
+	if _, err := file.AddUserWithHash("eva", ana.Password+",x", "devs"); err == nil {
+		t.Error("AddUserWithHash aceptó un hash con comas")
+	}
+	if err := file.RemoveUser("bob"); err != nil {
+		t.Fatalf("RemoveUser: %v", err)
+	}
+
+	if err := file.AddToGroup("ana", "root"); err != nil {
+		t.Fatalf("AddToGroup: %v", err)
+	}
+	if err := file.AddToGroup("ana", "devs"); err == nil {
+		t.Error("AddToGroup aceptó el grupo principal")
+	}
+	if err := file.SetGroup("ana", "root"); err != nil {
+		t.Fatalf("SetGroup: %v", err)
+	}
+	if err := file.RemoveFromGroup("ana", "devs"); err == nil {
+		t.Error("RemoveFromGroup aceptó un grupo que no es adicional")
+	}
+
+	// root y los grupos principales de usuarios activos no se eliminan
+	if err := file.RemoveGroup("root"); err == nil {
+		t.Error("RemoveGroup eliminó el grupo root")
+	}
+	if err := file.SetGroup("ana", "devs"); err != nil {
+		t.Fatalf("SetGroup: %v", err)
+	}
+	if err := file.RemoveGroup("devs"); err == nil {
+		t.Error("RemoveGroup eliminó el grupo principal de ana")
+	}
+	if err := file.SetGroup("ana", "root"); err != nil {
+		t.Fatalf("SetGroup: %v", err)
+	}
+
+	// Eliminar un grupo lo quita de los grupos adicionales
+	if err := file.AddToGroup("ana", "devs"); err != nil {
+		t.Fatalf("AddToGroup: %v", err)
+	}
+	if err := file.RemoveGroup("devs"); err != nil {
+		t.Fatalf("RemoveGroup: %v", err)
+	}
+	if groups := file.User("ana").Groups; !slices.Equal(groups, []string{"root"}) {
+		t.Errorf("grupos adicionales de ana = %v, se esperaba [root]", groups)
+	}
+
+	if err := file.RemoveUser("ana"); err != nil {
+		t.Fatalf("RemoveUser: %v", err)
+	}
+	if err := file.RemoveUser("ana"); err == nil {
+		t.Error("RemoveUser eliminó dos veces el mismo usuario")
+	}
+
+	// Los registros eliminados cuentan para el siguiente ID: el GID de devs y los UID de ana
+	// y bob no se vuelven a usar, porque los inodos todavía pueden tenerlos
+	ops, err := file.AddGroup("ops")
+	if err != nil || ops.ID != 3 {
+		t.Errorf("AddGroup(ops) = %+v, %v", ops, err)
+	}
+	eva, err := file.AddUser("eva", "x", "ops")
+	if err != nil || eva.ID != 4 {
+		t.Errorf("AddUser(eva) = %+v, %v", eva, err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	file, err := Parse("1,G,root\n1,U,root,123\n")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := file.Migrate(); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if file.Version != 2 || !strings.HasPrefix(file.String(), header+"\n") {
+		t.Errorf("el archivo no quedó en v2:\n%s", file.String())
+	}
+	root := file.User("root")
+	if root.Password == "123" || !root.CheckPassword("123") {
+		t.Errorf("la contraseña de root no se migró: %s", root.Password)
+	}
+
+	// Un archivo migrado se vuelve a leer como v2
+	again, err := Parse(file.String())
+	if err != nil || again.Version != 2 || !again.User("root").CheckPassword("123") {
+		t.Errorf("el archivo migrado no se pudo leer: %v", err)
+	}
+}
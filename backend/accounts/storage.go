@@ -0,0 +1,48 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+)
+
+// usersPath es la ruta de users.txt desde la raíz de la partición
+var usersPath = []string{"users.txt"}
+
+// Load lee e interpreta /users.txt de la partición
+func Load(sb *structures.SuperBlock, disk *structures.Disk) (*File, error) {
+	_, usersInode, err := sb.FindInodeByPath(disk, usersPath)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar users.txt: %v", err)
+	}
+	if usersInode.I_type[0] != '1' {
+		return nil, errors.New("users.txt no es un archivo válido")
+	}
+	content, err := sb.ReadFile(disk, usersInode)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer users.txt: %v", err)
+	}
+	return Parse(string(content))
+}
+
+// Save migra el archivo a v2 si hace falta y reemplaza /users.txt junto con el superbloque.
+// El contenido nuevo se escribe en bloques nuevos (ver SuperBlock.ReplaceFile): si la
+// escritura falla, users.txt conserva su contenido anterior completo.
+func Save(sb *structures.SuperBlock, disk *structures.Disk, file *File) error {
+	if err := file.Migrate(); err != nil {
+		return fmt.Errorf("error al calcular el hash de las contraseñas: %v", err)
+	}
+
+	usersInodeNum, usersInode, err := sb.FindInodeByPath(disk, usersPath)
+	if err != nil {
+		return fmt.Errorf("error al buscar users.txt: %v", err)
+	}
+	if err := sb.ReplaceFile(disk, usersInodeNum, usersInode, []byte(file.String())); err != nil {
+		return fmt.Errorf("error al escribir users.txt: %v", err)
+	}
+	if err := sb.Serialize(disk, disk.Start()); err != nil {
+		return fmt.Errorf("error al actualizar superbloque: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package accounts
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+)
+
+// newTestPartition crea una imagen con un EXT2 de 16 inodos y 48 bloques de 64 bytes con el users.txt inicial
+func newTestPartition(t *testing.T) (*structures.SuperBlock, *structures.Disk) {
+	t.Helper()
+	const inodes, blocks, blockSize = 16, 48, 64
+
+	sb := &structures.SuperBlock{
+		S_filesystem_type:   2,
+		S_inodes_count:      inodes,
+		S_blocks_count:      blocks,
+		S_free_inodes_count: inodes - 2,
+		S_free_blocks_count: blocks - 2,
+		S_magic:             0xEF53,
+		S_inode_size:        int32(binary.Size(structures.Inode{})),
+		S_block_size:        blockSize,
+	}
+	sb.S_bm_inode_start = int32(binary.Size(structures.SuperBlock{}))
+	sb.S_bm_block_start = sb.S_bm_inode_start + structures.BitmapSize(inodes, false)
+	sb.S_inode_start = sb.S_bm_block_start + structures.BitmapSize(blocks, false)
+	sb.S_block_start = sb.S_inode_start + sb.S_inode_size*inodes
+	size := int64(sb.S_block_start + blockSize*blocks)
+
+	path := filepath.Join(t.TempDir(), "accounts.mia")
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	disk, err := structures.OpenDisk(path, 0, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { disk.Close() })
+
+	users, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CreateBitMaps(disk); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CreateUsersFile(disk, users.String()); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.Serialize(disk, 0); err != nil {
+		t.Fatal(err)
+	}
+	return sb, disk
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	sb, disk := newTestPartition(t)
+
+	users, err := Load(sb, disk)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if root := users.User("root"); root == nil || !root.CheckPassword("123") {
+		t.Fatalf("el users.txt inicial no tiene a root: %s", users)
+	}
+
+	if _, err := users.AddGroup("devs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.AddUser("ana", "secreto", "devs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(sb, disk, users); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// El superbloque guardado refleja los bloques que ocupa ahora users.txt
+	var saved structures.SuperBlock
+	if err := saved.Deserialize(disk, 0); err != nil {
+		t.Fatal(err)
+	}
+	if saved.S_free_blocks_count != sb.S_free_blocks_count {
+		t.Errorf("bloques libres en el disco = %d, en memoria = %d", saved.S_free_blocks_count, sb.S_free_blocks_count)
+	}
+
+	reloaded, err := Load(&saved, disk)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if reloaded.String() != users.String() {
+		t.Errorf("users.txt releído:\n%s\nse esperaba:\n%s", reloaded, users)
+	}
+	if ana := reloaded.User("ana"); ana == nil || ana.ID != 2 || !ana.CheckPassword("secreto") {
+		t.Errorf("User(ana) = %+v", ana)
+	}
+}
+
+func TestSaveWithoutSpaceKeepsFile(t *testing.T) {
+	sb, disk := newTestPartition(t)
+
+	users, err := Load(sb, disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := users.String()
+
+	// Dejar solo dos bloques libres: no alcanzan para la versión nueva
+	if _, err := sb.AllocBlocks(disk, int(sb.S_free_blocks_count)-2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.AddGroup("devs"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ana", "bob", "carla"} {
+		if _, err := users.AddUser(name, "x", "devs"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Save(sb, disk, users); err == nil {
+		t.Fatal("Save no falló sin espacio")
+	}
+
+	if sb.S_free_blocks_count != 2 {
+		t.Errorf("bloques libres = %d, se esperaba 2", sb.S_free_blocks_count)
+	}
+	reloaded, err := Load(sb, disk)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if reloaded.String() != original {
+		t.Errorf("users.txt cambió tras el fallo:\n%s", reloaded)
+	}
+}
+
+func TestReplaceFileRollsBack(t *testing.T) {
+	sb, disk := newTestPartition(t)
+
+	// Más de 12 bloques de datos necesitan además un bloque de apuntadores: con 14 libres,
+	// la reserva de 14 bloques de datos funciona y la del apuntador falla a mitad de la escritura
+	if _, err := sb.AllocBlocks(disk, int(sb.S_free_blocks_count)-14); err != nil {
+		t.Fatal(err)
+	}
+	inodeNum, inode, err := sb.FindInodeByPath(disk, usersPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := sb.ReadFile(disk, inode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte(strings.Repeat("x", 14*int(sb.S_block_size)))
+	if err := sb.ReplaceFile(disk, inodeNum, inode, content); err == nil {
+		t.Fatal("ReplaceFile no falló sin bloque para los apuntadores")
+	}
+	if sb.S_free_blocks_count != 14 {
+		t.Errorf("bloques libres = %d, se esperaba 14: no se liberaron los bloques nuevos", sb.S_free_blocks_count)
+	}
+
+	_, inode, err = sb.FindInodeByPath(disk, usersPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := sb.ReadFile(disk, inode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("users.txt cambió tras el fallo:\n%s", after)
+	}
+
+	// 13 bloques de datos y su bloque de apuntadores sí caben
+	content = content[:13*int(sb.S_block_size)]
+	if err := sb.ReplaceFile(disk, inodeNum, inode, content); err != nil {
+		t.Fatalf("ReplaceFile: %v", err)
+	}
+	if after, _ := sb.ReadFile(disk, inode); string(after) != string(content) {
+		t.Error("el contenido nuevo no quedó escrito")
+	}
+}
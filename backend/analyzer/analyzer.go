@@ -1,48 +1,19 @@
 package analyzer
 
 import (
+	"context"
 	// Importa el paquete "errors" para manejar errores
 	"fmt"     // Importa el paquete "fmt" para formatear e imprimir texto
 	"strings" // Importa el paquete "strings" para manipulación de cadenas
 
 	commands "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/commands" // Importa el paquete "commands" que contiene las funciones para analizar comandos
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
 )
 
-// splitCommand divide la entrada respetando cadenas entre comillas
-// splitCommand divide la entrada respetando cadenas entre comillas
-func splitCommand(input string) []string {
-	var tokens []string
-	var currentToken strings.Builder
-	inQuotes := false
-
-	for i := 0; i < len(input); i++ {
-		char := input[i]
-
-		switch char {
-		case '"':
-			inQuotes = !inQuotes
-			// No agregamos las comillas al token, pero las respetamos en el proceso
-		case ' ':
-			if inQuotes {
-				currentToken.WriteByte(char)
-			} else if currentToken.Len() > 0 {
-				tokens = append(tokens, currentToken.String())
-				currentToken.Reset()
-			}
-		default:
-			currentToken.WriteByte(char)
-		}
-	}
-
-	if currentToken.Len() > 0 {
-		tokens = append(tokens, currentToken.String())
-	}
-
-	return tokens
-}
-
-// Analyzer analiza el comando de entrada y ejecuta la acción correspondiente
-func Analyzer(input string) (string, error) {
+// Analyzer analiza el comando de entrada y ejecuta la acción correspondiente.
+// ctx lleva el token de la sesión del cliente (ver stores.WithSessionToken).
+func Analyzer(ctx context.Context, input string) (string, error) {
 	// Eliminar espacios en blanco al inicio y final
 	input = strings.TrimSpace(input)
 	if input == "" {
@@ -50,7 +21,7 @@ func Analyzer(input string) (string, error) {
 	}
 
 	// Dividir la entrada en tokens respetando comillas
-	tokens := splitCommand(input)
+	tokens := utils.SplitCommand(input)
 	if len(tokens) == 0 {
 		return "", nil // Si no hay tokens válidos, devolvemos vacío
 	}
@@ -58,7 +29,131 @@ func Analyzer(input string) (string, error) {
 	// Convertir el comando a minúsculas para hacerlo case-insensitive
 	command := strings.ToLower(tokens[0])
 
-	// Ejecutar el comando correspondiente
+	// El candado cubre el comando y su registro en el journal
+	unlock := lockCommand(ctx, command, tokens[1:])
+	defer unlock()
+
+	// En EXT3 la operación se ejecuta con los mismos parámetros que quedan en el journal
+	if journaledCommands[command] {
+		params, err := commands.PrepareJournal(ctx, command, tokens[1:])
+		if err != nil {
+			return "", err
+		}
+		tokens = append([]string{tokens[0]}, params...)
+	}
+
+	output, err := execute(ctx, command, tokens)
+	if err != nil {
+		return "", err
+	}
+
+	// En EXT3 las operaciones que modifican el sistema de archivos quedan en el journal
+	if journaledCommands[command] {
+		if err := commands.RecordJournal(ctx, command, tokens[1:]); err != nil {
+			return output, fmt.Errorf("%s, pero no se pudo registrar en el journal: %v", output, err)
+		}
+	}
+
+	return output, nil
+}
+
+// journaledCommands son los comandos que modifican el sistema de archivos de la sesión
+var journaledCommands = map[string]bool{
+	"mkdir":   true,
+	"mkfile":  true,
+	"remove":  true,
+	"edit":    true,
+	"rename":  true,
+	"copy":    true,
+	"move":    true,
+	"chmod":   true,
+	"chown":   true,
+	"mkgrp":   true,
+	"rmgrp":   true,
+	"mkusr":   true,
+	"rmusr":   true,
+	"chgrp":   true,
+	"usermod": true,
+}
+
+// diskCommands cambian los MBR/EBR o las particiones montadas y se ejecutan solos
+var diskCommands = map[string]bool{
+	"mkdisk":  true,
+	"rmdisk":  true,
+	"fdisk":   true,
+	"mount":   true,
+	"unmount": true,
+}
+
+// partitionCommands reciben la partición con -id; el valor indica si la modifican
+var partitionCommands = map[string]bool{
+	"mkfs":       true,
+	"login":      true, // Migra users.txt en discos antiguos
+	"loss":       true,
+	"recovery":   true,
+	"fsck":       true,
+	"sync":       true,
+	"rep":        false,
+	"journaling": false,
+}
+
+// sessionReadCommands operan sobre la partición de la sesión sin modificarla; el resto de
+// los comandos de sesión (mkdir, mkfile, logout...) la modifican
+var sessionReadCommands = map[string]bool{
+	"cat":  true,
+	"find": true,
+}
+
+// lockCommand toma el candado que necesita el comando (ver stores/locks.go) y devuelve la función que lo libera
+func lockCommand(ctx context.Context, command string, params []string) func() {
+	switch {
+	case diskCommands[command]:
+		return stores.LockDisks()
+	case command == "mounted":
+		return func() {}
+	}
+
+	if write, ok := partitionCommands[command]; ok {
+		id := paramValue(params, "-id")
+		switch {
+		case id != "":
+			return stores.LockPartition(id, write)
+		case command == "sync":
+			return stores.LockDisks() // sync sin -id escribe todas las imágenes
+		default:
+			return func() {} // El comando fallará por falta de -id
+		}
+	}
+
+	// Comandos de sesión: la partición puede cambiar si otra petición con el mismo token
+	// cierra la sesión mientras se espera el candado, así que se verifica después de tomarlo
+	write := !sessionReadCommands[command]
+	for {
+		id := stores.SessionFromContext(ctx).ID
+		if id == "" {
+			return func() {} // El comando fallará por falta de sesión
+		}
+		unlock := stores.LockPartition(id, write)
+		if stores.SessionFromContext(ctx).ID == id {
+			return unlock
+		}
+		unlock()
+	}
+}
+
+// paramValue devuelve el valor del parámetro name (sin distinguir mayúsculas), o una cadena vacía
+func paramValue(params []string, name string) string {
+	for _, param := range params {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) == 2 && strings.ToLower(parts[0]) == name {
+			return strings.Trim(parts[1], "\"")
+		}
+	}
+	return ""
+}
+
+// execute ejecuta el comando correspondiente
+func execute(ctx context.Context, command string, tokens []string) (string, error) {
 	switch command {
 	case "mkdisk":
 		return commands.ParseMkdisk(tokens[1:])
@@ -68,6 +163,8 @@ func Analyzer(input string) (string, error) {
 		return commands.ParseFdisk(tokens[1:])
 	case "mount":
 		return commands.ParseMount(tokens[1:])
+	case "unmount":
+		return commands.ParseUnmount(tokens[1:])
 	case "mounted": // Asumo que esto es un comando personalizado para listar particiones montadas
 		return commands.ParseMounted(tokens[1:])
 	case "mkfs":
@@ -75,25 +172,53 @@ func Analyzer(input string) (string, error) {
 	case "rep":
 		return commands.ParseRep(tokens[1:])
 	case "mkdir":
-		return commands.ParseMkdir(tokens[1:])
+		return commands.ParseMkdir(ctx, tokens[1:])
 	case "login":
-		return commands.ParseLogin(tokens[1:])
+		return commands.ParseLogin(ctx, tokens[1:])
 	case "logout":
-		return commands.ParseLogout(tokens[1:])
+		return commands.ParseLogout(ctx, tokens[1:])
 	case "mkgrp":
-		return commands.ParseMkgrp(tokens[1:])
+		return commands.ParseMkgrp(ctx, tokens[1:])
 	case "mkfile":
-		return commands.ParseMkfile(tokens[1:])
+		return commands.ParseMkfile(ctx, tokens[1:])
 	case "rmgrp":
-		return commands.ParseRmgrp(tokens[1:])
+		return commands.ParseRmgrp(ctx, tokens[1:])
 	case "mkusr":
-		return commands.ParseMkusr(tokens[1:])
+		return commands.ParseMkusr(ctx, tokens[1:])
 	case "rmusr":
-		return commands.ParseRmusr(tokens[1:])
+		return commands.ParseRmusr(ctx, tokens[1:])
 	case "chgrp":
-		return commands.ParseChgrp(tokens[1:])
+		return commands.ParseChgrp(ctx, tokens[1:])
+	case "usermod":
+		return commands.ParseUsermod(ctx, tokens[1:])
 	case "cat":
-		return commands.ParseCat(tokens[1:])
+		return commands.ParseCat(ctx, tokens[1:])
+	case "remove":
+		return commands.ParseRemove(ctx, tokens[1:])
+	case "edit":
+		return commands.ParseEdit(ctx, tokens[1:])
+	case "rename":
+		return commands.ParseRename(ctx, tokens[1:])
+	case "copy":
+		return commands.ParseCopy(ctx, tokens[1:])
+	case "move":
+		return commands.ParseMove(ctx, tokens[1:])
+	case "find":
+		return commands.ParseFind(ctx, tokens[1:])
+	case "chmod":
+		return commands.ParseChmod(ctx, tokens[1:])
+	case "chown":
+		return commands.ParseChown(ctx, tokens[1:])
+	case "journaling":
+		return commands.ParseJournaling(tokens[1:])
+	case "loss":
+		return commands.ParseLoss(tokens[1:])
+	case "recovery":
+		return commands.ParseRecovery(tokens[1:])
+	case "fsck":
+		return commands.ParseFsck(tokens[1:])
+	case "sync":
+		return commands.ParseSync(tokens[1:])
 	default:
 		return "", fmt.Errorf("comando desconocido: %s", command)
 	}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -20,7 +21,7 @@ type CAT struct {
    cat -file1=/file.txt -file2=/folder/subfolder/newfile.txt
 */
 
-func ParseCat(tokens []string) (string, error) {
+func ParseCat(ctx context.Context, tokens []string) (string, error) {
 	cmd := &CAT{files: []string{}}
 
 	for _, token := range tokens {
@@ -40,7 +41,7 @@ func ParseCat(tokens []string) (string, error) {
 		return "", errors.New("faltan parámetros requeridos: al menos -file1 es obligatorio")
 	}
 
-	output, err := commandCat(cmd)
+	output, err := commandCat(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
@@ -48,19 +49,20 @@ func ParseCat(tokens []string) (string, error) {
 	return output, nil
 }
 
-func commandCat(cat *CAT) (string, error) {
-	if stores.CurrentSession.ID == "" {
+func commandCat(ctx context.Context, cat *CAT) (string, error) {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
 		return "", errors.New("debe iniciar sesión primero")
 	}
 
-	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(stores.CurrentSession.ID)
+	partitionSuperblock, _, partitionDisk, err := stores.GetMountedPartitionSuperblock(session.ID)
 	if err != nil {
 		return "", fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
 	var output strings.Builder
 	for i, filePath := range cat.files {
-		content, err := readFile(partitionSuperblock, partitionPath, filePath)
+		content, err := readFile(session, partitionSuperblock, partitionDisk, filePath)
 		if err != nil {
 			return "", fmt.Errorf("error al leer %s: %w", filePath, err)
 		}
@@ -73,106 +75,26 @@ func commandCat(cat *CAT) (string, error) {
 	return output.String(), nil
 }
 
-func readFile(sb *structures.SuperBlock, diskPath string, filePath string) (string, error) {
+func readFile(session *stores.Session, sb *structures.SuperBlock, disk *structures.Disk, filePath string) (string, error) {
 	parentDirs, fileName := utils.GetParentDirectories(filePath)
-	currentInode := int32(0) // Empezamos en la raíz
 
-	// Navegar a través de los directorios padres
-	for _, dir := range parentDirs {
-		inode := &structures.Inode{}
-		err := inode.Deserialize(diskPath, int64(sb.S_inode_start+currentInode*sb.S_inode_size))
-		if err != nil {
-			return "", err
-		}
-		if inode.I_type[0] != '0' {
-			return "", fmt.Errorf("ruta %s inválida: %s no es un directorio", filePath, dir)
-		}
-
-		found := false
-		for _, blockIndex := range inode.I_block {
-			if blockIndex == -1 {
-				break
-			}
-			block := &structures.FolderBlock{}
-			err = block.Deserialize(diskPath, int64(sb.S_block_start+blockIndex*sb.S_block_size))
-			if err != nil {
-				return "", err
-			}
-			for _, content := range block.B_content {
-				name := strings.Trim(string(content.B_name[:]), "\x00")
-				if name == dir && content.B_inodo != -1 {
-					currentInode = content.B_inodo
-					found = true
-					break
-				}
-			}
-			if found {
-				break
-			}
-		}
-		if !found {
-			return "", fmt.Errorf("directorio %s no encontrado en la ruta %s", dir, filePath)
-		}
-	}
-
-	// Buscar el archivo en el directorio final
-	inode := &structures.Inode{}
-	err := inode.Deserialize(diskPath, int64(sb.S_inode_start+currentInode*sb.S_inode_size))
+	// Navegar a través de los directorios padres verificando permisos
+	_, fileInode, err := resolvePath(session, sb, disk, append(parentDirs, fileName))
 	if err != nil {
-		return "", err
-	}
-	if inode.I_type[0] != '0' {
-		return "", fmt.Errorf("ruta %s inválida: el padre de %s no es un directorio", filePath, fileName)
+		return "", fmt.Errorf("ruta %s inválida: %w", filePath, err)
 	}
-
-	var fileInodeIndex int32 = -1
-	for _, blockIndex := range inode.I_block {
-		if blockIndex == -1 {
-			break
-		}
-		block := &structures.FolderBlock{}
-		err = block.Deserialize(diskPath, int64(sb.S_block_start+blockIndex*sb.S_block_size))
-		if err != nil {
-			return "", err
-		}
-		for _, content := range block.B_content {
-			name := strings.Trim(string(content.B_name[:]), "\x00")
-			if name == fileName && content.B_inodo != -1 {
-				fileInodeIndex = content.B_inodo
-				break
-			}
-		}
-		if fileInodeIndex != -1 {
-			break
-		}
+	if fileInode.I_type[0] != '1' {
+		return "", fmt.Errorf("%s no es un archivo", filePath)
 	}
-	if fileInodeIndex == -1 {
-		return "", fmt.Errorf("archivo %s no encontrado", filePath)
+	if err := checkPermission(session, fileInode, permRead, filePath); err != nil {
+		return "", err
 	}
 
-	// Leer el inodo del archivo
-	fileInode := &structures.Inode{}
-	err = fileInode.Deserialize(diskPath, int64(sb.S_inode_start+fileInodeIndex*sb.S_inode_size))
+	// Leer los bloques de datos (directos e indirectos)
+	content, err := sb.ReadFile(disk, fileInode)
 	if err != nil {
 		return "", err
 	}
-	if fileInode.I_type[0] != '1' {
-		return "", fmt.Errorf("%s no es un archivo", filePath)
-	}
-
-	// Leer los bloques de datos
-	var content strings.Builder
-	for _, blockIndex := range fileInode.I_block {
-		if blockIndex == -1 || blockIndex == 0 { // 0 podría ser un valor inicial no usado
-			break
-		}
-		fileBlock := &structures.FileBlock{}
-		err = fileBlock.Deserialize(diskPath, int64(sb.S_block_start+blockIndex*sb.S_block_size))
-		if err != nil {
-			return "", err
-		}
-		content.WriteString(strings.Trim(string(fileBlock.B_content[:]), "\x00"))
-	}
 
-	return content.String(), nil
+	return string(content), nil
 }
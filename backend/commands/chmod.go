@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// CHMOD estructura que representa el comando chmod con sus parámetros
+type CHMOD struct {
+	path string  // Ruta del archivo o carpeta
+	ugo  [3]byte // Permisos de propietario, grupo y otros como dígitos ASCII
+	r    bool    // Aplicar a todo el contenido de la carpeta
+}
+
+/*
+   chmod -path=/home/user/docs -ugo=764 -r
+*/
+
+func ParseChmod(ctx context.Context, tokens []string) (string, error) {
+	cmd := &CHMOD{}
+	hasUgo := false
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-path":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -path: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("la ruta debe ser absoluta (comenzar con /)")
+			}
+			cmd.path = value
+		case "-ugo":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -ugo: %s", token)
+			}
+			value := parts[1]
+			if len(value) != 3 {
+				return "", fmt.Errorf("permisos inválidos: %s, deben ser tres dígitos entre 0 y 7", value)
+			}
+			for i := range 3 {
+				if value[i] < '0' || value[i] > '7' {
+					return "", fmt.Errorf("permisos inválidos: %s, deben ser tres dígitos entre 0 y 7", value)
+				}
+				cmd.ugo[i] = value[i]
+			}
+			hasUgo = true
+		case "-r":
+			if len(parts) != 1 {
+				return "", fmt.Errorf("formato inválido para -r: %s", token)
+			}
+			cmd.r = true
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.path == "" || !hasUgo {
+		return "", errors.New("faltan parámetros requeridos: -path, -ugo")
+	}
+
+	err := commandChmod(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al cambiar permisos: %v", err)
+	}
+
+	return fmt.Sprintf("CHMOD: Permisos de %s cambiados a %s correctamente", cmd.path, string(cmd.ugo[:])), nil
+}
+
+func commandChmod(ctx context.Context, chmod *CHMOD) error {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return errors.New("debe iniciar sesión primero")
+	}
+
+	sb, _, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	parentDirs, name := utils.GetParentDirectories(chmod.path)
+	targetNum, target, err := resolvePath(session, sb, disk, append(parentDirs, name))
+	if err != nil {
+		return fmt.Errorf("ruta %s inválida: %w", chmod.path, err)
+	}
+	if !isOwner(session, target) {
+		return fmt.Errorf("solo root o el propietario pueden cambiar los permisos de %s", chmod.path)
+	}
+
+	if !chmod.r {
+		target.I_perm = chmod.ugo
+		return target.Serialize(disk, sb.InodeOffset(targetNum))
+	}
+
+	// En modo recursivo solo se modifican los elementos que pertenecen al usuario
+	return sb.WalkTree(disk, targetNum, chmod.path, func(inodeNum int32, inode *structures.Inode, _ string) error {
+		if !isOwner(session, inode) {
+			return nil
+		}
+		inode.I_perm = chmod.ugo
+		return inode.Serialize(disk, sb.InodeOffset(inodeNum))
+	})
+}
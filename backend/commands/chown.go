@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	accounts "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/accounts"
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// CHOWN estructura que representa el comando chown con sus parámetros
+type CHOWN struct {
+	path    string // Ruta del archivo o carpeta
+	usuario string // Nuevo propietario
+	r       bool   // Aplicar a todo el contenido de la carpeta
+}
+
+/*
+   chown -path=/home/user/docs -usuario=user2 -r
+*/
+
+func ParseChown(ctx context.Context, tokens []string) (string, error) {
+	cmd := &CHOWN{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-path":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -path: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("la ruta debe ser absoluta (comenzar con /)")
+			}
+			cmd.path = value
+		case "-usuario":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -usuario: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if value == "" {
+				return "", errors.New("el usuario no puede estar vacío")
+			}
+			cmd.usuario = value
+		case "-r":
+			if len(parts) != 1 {
+				return "", fmt.Errorf("formato inválido para -r: %s", token)
+			}
+			cmd.r = true
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.path == "" || cmd.usuario == "" {
+		return "", errors.New("faltan parámetros requeridos: -path, -usuario")
+	}
+
+	err := commandChown(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al cambiar propietario: %v", err)
+	}
+
+	return fmt.Sprintf("CHOWN: Propietario de %s cambiado a %s correctamente", cmd.path, cmd.usuario), nil
+}
+
+func commandChown(ctx context.Context, chown *CHOWN) error {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return errors.New("debe iniciar sesión primero")
+	}
+
+	sb, _, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	users, err := accounts.Load(sb, disk)
+	if err != nil {
+		return err
+	}
+	owner := users.User(chown.usuario)
+	if owner == nil {
+		return fmt.Errorf("el usuario %s no existe", chown.usuario)
+	}
+	newUID := owner.ID
+
+	parentDirs, name := utils.GetParentDirectories(chown.path)
+	targetNum, target, err := resolvePath(session, sb, disk, append(parentDirs, name))
+	if err != nil {
+		return fmt.Errorf("ruta %s inválida: %w", chown.path, err)
+	}
+	if !isOwner(session, target) {
+		return fmt.Errorf("solo root o el propietario pueden cambiar el propietario de %s", chown.path)
+	}
+
+	if !chown.r {
+		target.I_uid = newUID
+		return target.Serialize(disk, sb.InodeOffset(targetNum))
+	}
+
+	// En modo recursivo solo se modifican los elementos que pertenecen al usuario
+	return sb.WalkTree(disk, targetNum, chown.path, func(inodeNum int32, inode *structures.Inode, _ string) error {
+		if !isOwner(session, inode) {
+			return nil
+		}
+		inode.I_uid = newUID
+		return inode.Serialize(disk, sb.InodeOffset(inodeNum))
+	})
+}
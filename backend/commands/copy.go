@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// COPY estructura que representa el comando copy con sus parámetros
+type COPY struct {
+	path    string // Ruta del archivo o carpeta a copiar
+	destino string // Carpeta en la que se crea la copia
+}
+
+/*
+   copy -path=/home/user/docs -destino=/home/images
+*/
+
+func ParseCopy(ctx context.Context, tokens []string) (string, error) {
+	cmd := &COPY{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-path":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -path: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("la ruta debe ser absoluta (comenzar con /)")
+			}
+			cmd.path = value
+		case "-destino":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -destino: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("el destino debe ser una ruta absoluta (comenzar con /)")
+			}
+			cmd.destino = value
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.path == "" || cmd.destino == "" {
+		return "", errors.New("faltan parámetros requeridos: -path, -destino")
+	}
+
+	err := commandCopy(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al copiar: %v", err)
+	}
+
+	return fmt.Sprintf("COPY: %s copiado a %s correctamente", cmd.path, cmd.destino), nil
+}
+
+func commandCopy(ctx context.Context, cp *COPY) error {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return errors.New("debe iniciar sesión primero")
+	}
+
+	sb, mountedPartition, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	parentDirs, name := utils.GetParentDirectories(cp.path)
+	if name == "" {
+		return errors.New("no se puede copiar la raíz")
+	}
+	srcNum, src, err := resolvePath(session, sb, disk, append(parentDirs, name))
+	if err != nil {
+		return fmt.Errorf("%s no existe: %w", cp.path, err)
+	}
+	if err := checkPermission(session, src, permRead, cp.path); err != nil {
+		return err
+	}
+
+	destDirs, destName := utils.GetParentDirectories(cp.destino)
+	destNum, dest, err := ensureDirectory(session, sb, disk, append(destDirs, destName), false)
+	if err != nil {
+		return fmt.Errorf("destino %s inválido: %w", cp.destino, err)
+	}
+	if err := checkPermission(session, dest, permWrite, cp.destino); err != nil {
+		return err
+	}
+
+	// Copiar una carpeta dentro de sí misma no terminaría nunca
+	inside, err := sb.IsAncestor(disk, srcNum, destNum)
+	if err != nil {
+		return err
+	}
+	if inside {
+		return fmt.Errorf("no se puede copiar %s dentro de sí mismo", cp.path)
+	}
+
+	existing, err := sb.FindEntry(disk, dest, name)
+	if err != nil {
+		return err
+	}
+	if existing != -1 {
+		return fmt.Errorf("%s ya existe en %s", name, cp.destino)
+	}
+
+	// La copia pertenece al usuario que la crea; los elementos sin permiso de lectura no se copian
+	uid, gid, err := sessionOwner(session)
+	if err != nil {
+		return err
+	}
+	copyNum, err := sb.CloneInode(disk, srcNum, destNum, uid, gid, func(inode *structures.Inode) bool {
+		return !hasPermission(session, inode, permRead)
+	})
+	if err != nil {
+		return err
+	}
+	err = sb.AddEntry(disk, destNum, dest, name, copyNum)
+	if err != nil {
+		sb.DeleteInode(disk, copyNum)
+		return err
+	}
+
+	// Serializar el superbloque con los nuevos conteos libres
+	err = sb.Serialize(disk, int64(mountedPartition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error al serializar el superbloque: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// EDIT estructura que representa el comando edit con sus parámetros
+type EDIT struct {
+	path      string // Ruta del archivo dentro del sistema de archivos
+	contenido string // Ruta del archivo en el sistema anfitrión con el nuevo contenido
+	data      []byte // Contenido ya leído (-contenido64), en lugar de contenido
+	append    bool   // Agregar al final en lugar de reemplazar
+}
+
+/*
+   edit -path=/home/config.txt -contenido=/home/user/config.txt
+   edit -path=/home/config.txt -contenido=/home/user/extra.txt -append
+   edit -path=/home/config.txt -contenido64=K2xpbmVhCg==
+
+En EXT3 el journal guarda el contenido en base64 (-contenido64) y no la ruta del anfitrión,
+para que recovery escriba lo mismo aunque el archivo del anfitrión cambie o ya no exista.
+*/
+
+func ParseEdit(ctx context.Context, tokens []string) (string, error) {
+	cmd := &EDIT{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-path":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -path: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("la ruta debe ser absoluta (comenzar con /)")
+			}
+			cmd.path = value
+		case "-contenido":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -contenido: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if value == "" {
+				return "", errors.New("el contenido no puede estar vacío")
+			}
+			cmd.contenido = value
+		case "-contenido64":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -contenido64: %s", token)
+			}
+			data, err := base64.StdEncoding.DecodeString(strings.Trim(parts[1], "\""))
+			if err != nil {
+				return "", fmt.Errorf("contenido en base64 inválido: %v", err)
+			}
+			cmd.data = data
+		case "-append":
+			if len(parts) != 1 {
+				return "", fmt.Errorf("formato inválido para -append: %s", token)
+			}
+			cmd.append = true
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.path == "" || cmd.contenido == "" && cmd.data == nil {
+		return "", errors.New("faltan parámetros requeridos: -path, -contenido")
+	}
+	if cmd.contenido != "" && cmd.data != nil {
+		return "", errors.New("use -contenido o -contenido64, no ambos")
+	}
+
+	err := commandEdit(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al editar el archivo: %v", err)
+	}
+
+	return fmt.Sprintf("EDIT: Archivo %s editado correctamente", cmd.path), nil
+}
+
+func commandEdit(ctx context.Context, edit *EDIT) error {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return errors.New("debe iniciar sesión primero")
+	}
+
+	parentDirs, fileName := utils.GetParentDirectories(edit.path)
+	if len(parentDirs) == 0 && fileName == "users.txt" {
+		return errors.New("no se puede editar /users.txt, use los comandos de usuarios y grupos")
+	}
+
+	// Leer el contenido nuevo desde el sistema anfitrión
+	newContent := edit.data
+	if edit.contenido != "" {
+		var err error
+		if newContent, err = os.ReadFile(edit.contenido); err != nil {
+			return fmt.Errorf("error al leer %s: %v", edit.contenido, err)
+		}
+	}
+
+	sb, mountedPartition, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	inodeNum, inode, err := resolvePath(session, sb, disk, append(parentDirs, fileName))
+	if err != nil {
+		return fmt.Errorf("ruta %s inválida: %w", edit.path, err)
+	}
+	if inode.I_type[0] != '1' {
+		return fmt.Errorf("%s no es un archivo", edit.path)
+	}
+	if err := checkPermission(session, inode, permWrite, edit.path); err != nil {
+		return err
+	}
+
+	content := newContent
+	if edit.append {
+		current, err := sb.ReadFile(disk, inode)
+		if err != nil {
+			return err
+		}
+		content = append(current, newContent...)
+	}
+
+	err = sb.WriteFile(disk, inodeNum, inode, content)
+	if err != nil {
+		return err
+	}
+
+	// Serializar el superbloque con los nuevos conteos libres
+	err = sb.Serialize(disk, int64(mountedPartition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error al serializar el superbloque: %w", err)
+	}
+
+	return nil
+}
+
+// inlineEditContent cambia -contenido por -contenido64 con el contenido del archivo del anfitrión,
+// para que edit quede en el journal con los datos que escribió. Si el archivo no se puede leer,
+// el parámetro se deja para que commandEdit informe el error.
+func inlineEditContent(tokens []string) []string {
+	inlined := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		if len(parts) == 2 && strings.ToLower(parts[0]) == "-contenido" {
+			if data, err := os.ReadFile(strings.Trim(parts[1], "\"")); err == nil {
+				token = "-contenido64=" + base64.StdEncoding.EncodeToString(data)
+			}
+		}
+		inlined = append(inlined, token)
+	}
+	return inlined
+}
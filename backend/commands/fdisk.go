@@ -4,10 +4,10 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
 	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
 	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
 )
@@ -20,8 +20,18 @@ type FDISK struct {
 	path string // Ruta del archivo del disco
 	typ  string // Tipo de partición (P, E, L)
 	name string // Nombre de la partición
+	del  string // Modo de eliminación (fast, full)
+	add  int    // Cantidad a agregar (positiva) o quitar (negativa), en la unidad indicada
 }
 
+/*
+	fdisk -size=300 -path=/home/Disco1.mia -name=Particion1
+	fdisk -type=E -path=/home/Disco2.mia -unit=K -name=Particion2 -size=300
+	fdisk -delete=fast -name=Particion1 -path=/home/Disco1.mia
+	fdisk -delete=full -name=Particion2 -path=/home/Disco2.mia
+	fdisk -add=-500 -unit=K -path=/home/Disco1.mia -name=Particion1
+*/
+
 // ParseFdisk parsea el comando fdisk y devuelve una instancia de FDISK
 func ParseFdisk(tokens []string) (string, error) {
 	cmd := &FDISK{}
@@ -70,26 +80,57 @@ func ParseFdisk(tokens []string) (string, error) {
 				return "", errors.New("el nombre no puede estar vacío")
 			}
 			cmd.name = value
+		case "-delete":
+			value = strings.ToLower(value)
+			if value != "fast" && value != "full" {
+				return "", errors.New("el modo de eliminación debe ser fast o full")
+			}
+			cmd.del = value
+		case "-add":
+			add, err := strconv.Atoi(value)
+			if err != nil || add == 0 {
+				return "", errors.New("el valor de -add debe ser un entero distinto de cero")
+			}
+			cmd.add = add
 		default:
 			return "", fmt.Errorf("parámetro desconocido: %s", key)
 		}
 	}
 
 	// Validar parámetros requeridos
-	if cmd.size == 0 {
-		return "", errors.New("faltan parámetros requeridos: -size")
-	}
 	if cmd.path == "" {
 		return "", errors.New("faltan parámetros requeridos: -path")
 	}
 	if cmd.name == "" {
 		return "", errors.New("faltan parámetros requeridos: -name")
 	}
+	if cmd.del != "" && cmd.add != 0 {
+		return "", errors.New("los parámetros -delete y -add no se pueden usar juntos")
+	}
 
 	// Establecer valores por defecto
 	if cmd.unit == "" {
 		cmd.unit = "K" // Cambiado de "M" a "K" según especificaciones
 	}
+
+	if cmd.del != "" {
+		if err := commandFdiskDelete(cmd); err != nil {
+			return "", fmt.Errorf("error al eliminar la partición: %v", err)
+		}
+		return fmt.Sprintf("FDISK: Partición %s eliminada correctamente de %s", cmd.name, cmd.path), nil
+	}
+
+	if cmd.add != 0 {
+		newSize, err := commandFdiskAdd(cmd)
+		if err != nil {
+			return "", fmt.Errorf("error al redimensionar la partición: %v", err)
+		}
+		return fmt.Sprintf("FDISK: Partición %s redimensionada correctamente, nuevo tamaño: %d bytes", cmd.name, newSize), nil
+	}
+
+	if cmd.size == 0 {
+		return "", errors.New("faltan parámetros requeridos: -size")
+	}
 	if cmd.fit == "" {
 		cmd.fit = "WF"
 	}
@@ -98,30 +139,30 @@ func ParseFdisk(tokens []string) (string, error) {
 	}
 
 	// Ejecutar el comando
-	err := commandFdisk(cmd)
+	start, err := commandFdisk(cmd)
 	if err != nil {
 		return "", fmt.Errorf("error al crear la partición: %v", err)
 	}
 
-	return fmt.Sprintf("FDISK: Partición %s creada correctamente en %s", cmd.name, cmd.path), nil
+	return fmt.Sprintf("FDISK: Partición %s creada correctamente en %s (inicio: byte %d)", cmd.name, cmd.path, start), nil
 }
 
-// commandFdisk implementa la lógica para crear la partición
-func commandFdisk(fdisk *FDISK) error {
+// commandFdisk implementa la lógica para crear la partición y devuelve el byte donde quedó
+func commandFdisk(fdisk *FDISK) (int32, error) {
 	// Convertir el tamaño a bytes
 	sizeBytes, err := utils.ConvertToBytes(fdisk.size, fdisk.unit)
 	if err != nil {
-		return fmt.Errorf("error al convertir tamaño: %v", err)
+		return 0, fmt.Errorf("error al convertir tamaño: %v", err)
 	}
 
 	var mbr structures.MBR
 	if err := mbr.Deserialize(fdisk.path); err != nil {
-		return fmt.Errorf("error al deserializar MBR: %v", err)
+		return 0, fmt.Errorf("error al deserializar MBR: %v", err)
 	}
 
 	// Validar nombre duplicado en primarias/extendidas
 	if _, idx := mbr.GetPartitionByName(fdisk.name); idx != -1 {
-		return fmt.Errorf("el nombre '%s' ya existe en particiones primarias/extendidas", fdisk.name)
+		return 0, fmt.Errorf("el nombre '%s' ya existe en particiones primarias/extendidas", fdisk.name)
 	}
 
 	switch fdisk.typ {
@@ -132,182 +173,425 @@ func commandFdisk(fdisk *FDISK) error {
 	case "L":
 		return createLogicalPartition(fdisk, sizeBytes)
 	default:
-		return errors.New("tipo de partición inválido")
+		return 0, errors.New("tipo de partición inválido")
 	}
 }
 
-// createPrimaryPartition crea una partición primaria
-func createPrimaryPartition(fdisk *FDISK, sizeBytes int) error {
+// createPrimaryPartition crea una partición primaria en el hueco que indique el ajuste del disco
+func createPrimaryPartition(fdisk *FDISK, sizeBytes int) (int32, error) {
 	var mbr structures.MBR
 	if err := mbr.Deserialize(fdisk.path); err != nil {
-		return fmt.Errorf("error deserializando el MBR: %v", err)
+		return 0, fmt.Errorf("error deserializando el MBR: %v", err)
 	}
 
-	// Contar particiones primarias/extendidas activas
-	count := 0
-	for _, p := range mbr.Mbr_partitions {
-		if p.Part_status[0] != 'N' {
-			count++
-		}
+	partition, start, idx, err := mbr.GetAvailablePartition(int32(sizeBytes))
+	if err != nil {
+		return 0, err
 	}
-	if count >= 4 {
-		return errors.New("máximo de 4 particiones primarias/extendidas alcanzado")
+
+	partition.CreatePartition(start, sizeBytes, fdisk.typ, fdisk.fit, fdisk.name)
+	mbr.Mbr_partitions[idx] = *partition
+	if err := mbr.Serialize(fdisk.path); err != nil {
+		return 0, fmt.Errorf("error serializando el MBR: %v", err)
 	}
 
-	partition, start, idx := mbr.GetFirstAvailablePartition()
-	if partition == nil {
-		return errors.New("no hay particiones disponibles en el MBR")
+	return int32(start), nil
+}
+
+// createExtendedPartition crea una partición extendida en el hueco que indique el ajuste del disco
+func createExtendedPartition(fdisk *FDISK, sizeBytes int) (int32, error) {
+	var mbr structures.MBR
+	if err := mbr.Deserialize(fdisk.path); err != nil {
+		return 0, fmt.Errorf("error deserializando el MBR: %v", err)
 	}
 
-	// Verificar espacio disponible desde el inicio hasta el final del disco
-	availableSpace := int(mbr.Mbr_size) - start
-	if sizeBytes > availableSpace {
-		return errors.New("no hay espacio suficiente en el disco")
+	// Validar que no exista otra extendida
+	if ext, _ := extendedPartition(&mbr); ext != nil {
+		return 0, errors.New("ya existe una partición extendida en el disco")
 	}
 
-	partition.CreatePartition(start, sizeBytes, fdisk.typ, fdisk.fit, fdisk.name)
+	partition, start, idx, err := mbr.GetAvailablePartition(int32(sizeBytes))
+	if err != nil {
+		return 0, err
+	}
+
+	partition.CreatePartition(start, sizeBytes, "E", fdisk.fit, fdisk.name)
 	mbr.Mbr_partitions[idx] = *partition
 	if err := mbr.Serialize(fdisk.path); err != nil {
-		return fmt.Errorf("error serializando el MBR: %v", err)
+		return 0, fmt.Errorf("error serializando el MBR: %v", err)
 	}
 
-	return nil
+	// Dejar la cadena de EBR vacía, sin restos de lo que hubiera antes en ese espacio
+	image, err := structures.OpenImage(fdisk.path)
+	if err != nil {
+		return 0, fmt.Errorf("error al abrir disco: %v", err)
+	}
+	defer image.Close()
+	if err := emptyEBR().Serialize(image, int64(start)); err != nil {
+		return 0, fmt.Errorf("error al inicializar el primer EBR: %v", err)
+	}
+
+	return int32(start), nil
 }
 
-// createExtendedPartition crea una partición extendida
-func createExtendedPartition(fdisk *FDISK, sizeBytes int) error {
+// createLogicalPartition crea una partición lógica en el hueco de la extendida que indique
+// el ajuste de la extendida. El EBR de la lógica se escribe al inicio de su espacio y se
+// enlaza en la cadena en orden de posición.
+func createLogicalPartition(fdisk *FDISK, sizeBytes int) (int32, error) {
 	var mbr structures.MBR
 	if err := mbr.Deserialize(fdisk.path); err != nil {
-		return fmt.Errorf("error deserializando el MBR: %v", err)
+		return 0, fmt.Errorf("error al deserializar MBR: %v", err)
 	}
 
-	// Validar que no exista otra extendida
-	for _, p := range mbr.Mbr_partitions {
-		if p.Part_type[0] == 'E' && p.Part_status[0] != 'N' {
-			return errors.New("ya existe una partición extendida en el disco")
+	// Buscar partición extendida
+	ext, _ := extendedPartition(&mbr)
+	if ext == nil {
+		return 0, errors.New("no hay partición extendida para crear lógicas")
+	}
+
+	image, err := structures.OpenImage(fdisk.path)
+	if err != nil {
+		return 0, fmt.Errorf("error al abrir disco: %v", err)
+	}
+	defer image.Close()
+
+	chain, err := readLogicalPartitions(image, ext)
+	if err != nil {
+		return 0, err
+	}
+	if findLogicalPartition(chain, fdisk.name) != -1 {
+		return 0, fmt.Errorf("el nombre '%s' ya existe en particiones lógicas", fdisk.name)
+	}
+
+	// Una lógica ocupa al menos lo que mide su EBR
+	needed := max(int32(sizeBytes), int32(binary.Size(structures.EBR{})))
+	gap, ok := structures.SelectFit(logicalFreeSegments(ext, chain), needed, ext.Part_fit[0])
+	if !ok {
+		return 0, errors.New("no hay espacio suficiente en la partición extendida")
+	}
+
+	newEBR := structures.EBR{
+		Part_status: [1]byte{'0'},
+		Part_fit:    [1]byte{fdisk.fit[0]},
+		Part_start:  gap.Start,
+		Part_size:   int32(sizeBytes),
+		Part_next:   -1,
+	}
+	copy(newEBR.Part_name[:], fdisk.name)
+
+	// Sin lógicas, la extendida está libre completa y el nuevo EBR queda al inicio de ella
+	if len(chain) == 0 {
+		if err := newEBR.Serialize(image, int64(gap.Start)); err != nil {
+			return 0, fmt.Errorf("error al crear primer EBR: %v", err)
 		}
+		return gap.Start, nil
 	}
 
-	// Contar particiones primarias/extendidas activas
-	count := 0
-	for _, p := range mbr.Mbr_partitions {
-		if p.Part_status[0] != 'N' {
-			count++
+	// El EBR anterior es el último que está antes del hueco; el primero siempre está al inicio de la extendida
+	prev := chain[0]
+	for _, logical := range chain[1:] {
+		if logical.offset < int64(gap.Start) {
+			prev = logical
 		}
 	}
-	if count >= 4 {
-		return errors.New("máximo de 4 particiones primarias/extendidas alcanzado")
+	newEBR.Part_next = prev.ebr.Part_next
+	if err := newEBR.Serialize(image, int64(gap.Start)); err != nil {
+		return 0, fmt.Errorf("error al crear nuevo EBR: %v", err)
+	}
+	prev.ebr.Part_next = gap.Start
+	if err := prev.ebr.Serialize(image, prev.offset); err != nil {
+		return 0, fmt.Errorf("error al actualizar EBR anterior: %v", err)
 	}
 
-	partition, start, idx := mbr.GetFirstAvailablePartition()
-	if partition == nil {
-		return errors.New("no hay particiones disponibles en el MBR")
+	return gap.Start, nil
+}
+
+// emptyEBR devuelve el EBR que marca una cadena sin particiones lógicas
+func emptyEBR() *structures.EBR {
+	return &structures.EBR{
+		Part_status: [1]byte{'N'},
+		Part_fit:    [1]byte{'N'},
+		Part_start:  -1,
+		Part_next:   -1,
 	}
+}
+
+// logicalPartition es un EBR junto con la posición del disco en la que está escrito
+type logicalPartition struct {
+	offset int64
+	ebr    structures.EBR
+}
 
-	availableSpace := int(mbr.Mbr_size) - start
-	if sizeBytes > availableSpace {
-		return errors.New("no hay espacio suficiente en el disco")
+// extendedPartition devuelve la partición extendida del MBR y su índice
+func extendedPartition(mbr *structures.MBR) (*structures.Partition, int) {
+	for i := range mbr.Mbr_partitions {
+		p := &mbr.Mbr_partitions[i]
+		if p.Part_type[0] == 'E' && p.Part_status[0] != 'N' {
+			return p, i
+		}
 	}
+	return nil, -1
+}
 
-	partition.CreatePartition(start, sizeBytes, "E", fdisk.fit, fdisk.name)
-	mbr.Mbr_partitions[idx] = *partition
-	if err := mbr.Serialize(fdisk.path); err != nil {
-		return fmt.Errorf("error serializando el MBR: %v", err)
+// readLogicalPartitions devuelve la cadena de EBR de la partición extendida
+func readLogicalPartitions(image *structures.Disk, ext *structures.Partition) ([]logicalPartition, error) {
+	var chain []logicalPartition
+	currentOffset := int64(ext.Part_start)
+	for {
+		var currentEBR structures.EBR
+		if err := currentEBR.Deserialize(image, currentOffset); err != nil {
+			return nil, fmt.Errorf("error al leer EBR: %v", err)
+		}
+		// Un primer EBR vacío indica que la extendida no tiene lógicas
+		if currentEBR.Part_status[0] == 0 || currentEBR.Part_status[0] == 'N' {
+			return chain, nil
+		}
+		chain = append(chain, logicalPartition{offset: currentOffset, ebr: currentEBR})
+		if currentEBR.Part_next == -1 {
+			return chain, nil
+		}
+		currentOffset = int64(currentEBR.Part_next)
+	}
+}
+
+// findLogicalPartition devuelve la posición en la cadena de la lógica con el nombre indicado, o -1
+func findLogicalPartition(chain []logicalPartition, name string) int {
+	for i, logical := range chain {
+		if strings.Trim(string(logical.ebr.Part_name[:]), "\x00") == name {
+			return i
+		}
 	}
+	return -1
+}
+
+// logicalFreeSegments devuelve los huecos libres de la extendida. Cada lógica ocupa su EBR y sus datos,
+// que pueden no ser contiguos si el primer EBR se reemplazó al eliminar la primera lógica.
+func logicalFreeSegments(ext *structures.Partition, chain []logicalPartition) []structures.Segment {
+	ebrSize := int32(binary.Size(structures.EBR{}))
+	var used []structures.Segment
+	for _, logical := range chain {
+		used = append(used,
+			structures.Segment{Start: int32(logical.offset), Size: ebrSize},
+			structures.Segment{Start: logical.ebr.Part_start, Size: logical.ebr.Part_size},
+		)
+	}
+	return structures.FreeSegments(ext.Part_start, ext.Part_start+ext.Part_size, used)
+}
+
+// isMountedPartition indica si la partición con el ID indicado está montada desde el disco path
+func isMountedPartition(path string, id [4]byte) bool {
+	partID := strings.Trim(string(id[:]), "\x00")
+	mountedPath, _ := stores.MountedPath(partID)
+	return partID != "" && mountedPath == path
+}
 
+// zeroRange llena con ceros size bytes del disco a partir de start
+func zeroRange(image *structures.Disk, start int64, size int64) error {
+	if err := image.Zero(start, size); err != nil {
+		return fmt.Errorf("error al limpiar la partición: %v", err)
+	}
 	return nil
 }
 
-// createLogicalPartition crea una partición lógica dentro de una extendida
-func createLogicalPartition(fdisk *FDISK, sizeBytes int) error {
+// formattedSize devuelve los bytes que ocupa el sistema de archivos de la partición que inicia en start,
+// o 0 si la partición no está formateada
+func formattedSize(image *structures.Disk, start int32) int32 {
+	var sb structures.SuperBlock
+	if err := sb.Deserialize(image, int64(start)); err != nil || sb.S_magic != 0xEF53 {
+		return 0
+	}
+	return sb.S_block_start + sb.S_blocks_count*sb.S_block_size - start
+}
+
+// commandFdiskDelete elimina una partición primaria, extendida (con sus lógicas) o lógica
+func commandFdiskDelete(fdisk *FDISK) error {
 	var mbr structures.MBR
 	if err := mbr.Deserialize(fdisk.path); err != nil {
 		return fmt.Errorf("error al deserializar MBR: %v", err)
 	}
 
-	// Buscar partición extendida
-	var extPartition *structures.Partition
-	for _, p := range mbr.Mbr_partitions {
-		if p.Part_type[0] == 'E' && p.Part_status[0] != 'N' {
-			extPartition = &p
-			break
-		}
-	}
-	if extPartition == nil {
-		return errors.New("no hay partición extendida para crear lógicas")
-	}
-
-	file, err := os.OpenFile(fdisk.path, os.O_RDWR, 0644)
+	image, err := structures.OpenImage(fdisk.path)
 	if err != nil {
 		return fmt.Errorf("error al abrir disco: %v", err)
 	}
-	defer file.Close()
+	defer image.Close()
 
-	startExt := int64(extPartition.Part_start)
-	availableSpace := int(extPartition.Part_size)
+	if _, idx := mbr.GetPartitionByName(fdisk.name); idx != -1 {
+		partition := &mbr.Mbr_partitions[idx]
+		if isMountedPartition(fdisk.path, partition.Part_id) {
+			return errors.New("la partición está montada, desmonte primero")
+		}
+		// Al eliminar la extendida se eliminan también sus lógicas
+		if partition.Part_type[0] == 'E' {
+			chain, err := readLogicalPartitions(image, partition)
+			if err != nil {
+				return err
+			}
+			for _, logical := range chain {
+				if isMountedPartition(fdisk.path, logical.ebr.Part_id) {
+					return fmt.Errorf("la partición lógica %s está montada, desmonte primero", strings.Trim(string(logical.ebr.Part_name[:]), "\x00"))
+				}
+			}
+		}
 
-	var currentEBR structures.EBR
-	err = currentEBR.Deserialize(file, startExt)
-	if err != nil || currentEBR.Part_status[0] == 0 || currentEBR.Part_status[0] == 'N' {
-		// Primer EBR
-		ebrSize := int(binary.Size(structures.EBR{}))
-		if sizeBytes+ebrSize > availableSpace {
-			return errors.New("no hay espacio suficiente en la partición extendida")
+		if fdisk.del == "full" {
+			if err := zeroRange(image, int64(partition.Part_start), int64(partition.Part_size)); err != nil {
+				return err
+			}
+		}
+		partition.DeletePartition()
+		if err := mbr.Serialize(fdisk.path); err != nil {
+			return fmt.Errorf("error al serializar MBR: %v", err)
 		}
-		currentEBR = structures.EBR{
-			Part_status: [1]byte{'0'},
-			Part_fit:    [1]byte{fdisk.fit[0]},
-			Part_start:  extPartition.Part_start,
-			Part_size:   int32(sizeBytes),
-			Part_next:   -1,
+		return nil
+	}
+
+	ext, _ := extendedPartition(&mbr)
+	if ext == nil {
+		return fmt.Errorf("la partición %s no existe en el disco", fdisk.name)
+	}
+	chain, err := readLogicalPartitions(image, ext)
+	if err != nil {
+		return err
+	}
+	i := findLogicalPartition(chain, fdisk.name)
+	if i == -1 {
+		return fmt.Errorf("la partición %s no existe en el disco", fdisk.name)
+	}
+	logical := chain[i].ebr
+	if isMountedPartition(fdisk.path, logical.Part_id) {
+		return errors.New("la partición está montada, desmonte primero")
+	}
+
+	if fdisk.del == "full" {
+		if err := zeroRange(image, int64(logical.Part_start), int64(logical.Part_size)); err != nil {
+			return err
 		}
-		copy(currentEBR.Part_name[:], fdisk.name)
-		if err := currentEBR.Serialize(file, startExt); err != nil {
-			return fmt.Errorf("error al crear primer EBR: %v", err)
+	}
+
+	// Desenlazar el EBR de la cadena. El primer EBR siempre está al inicio de la extendida,
+	// así que si se elimina se reemplaza por el siguiente o se marca como vacío.
+	if i > 0 {
+		prev := chain[i-1]
+		prev.ebr.Part_next = logical.Part_next
+		if err := prev.ebr.Serialize(image, prev.offset); err != nil {
+			return fmt.Errorf("error al actualizar EBR anterior: %v", err)
 		}
 		return nil
 	}
+	head := *emptyEBR()
+	if len(chain) > 1 {
+		head = chain[1].ebr
+	}
+	if err := head.Serialize(image, chain[0].offset); err != nil {
+		return fmt.Errorf("error al actualizar el primer EBR: %v", err)
+	}
+	return nil
+}
 
-	// Recorrer EBRs existentes
-	currentOffset := startExt
-	for {
-		if string(currentEBR.Part_name[:len(fdisk.name)]) == fdisk.name {
-			return fmt.Errorf("el nombre '%s' ya existe en particiones lógicas", fdisk.name)
+// commandFdiskAdd agrega o quita espacio al final de una partición y devuelve su nuevo tamaño
+func commandFdiskAdd(fdisk *FDISK) (int32, error) {
+	delta, err := utils.ConvertToBytes(fdisk.add, fdisk.unit)
+	if err != nil {
+		return 0, fmt.Errorf("error al convertir tamaño: %v", err)
+	}
+
+	var mbr structures.MBR
+	if err := mbr.Deserialize(fdisk.path); err != nil {
+		return 0, fmt.Errorf("error al deserializar MBR: %v", err)
+	}
+
+	image, err := structures.OpenImage(fdisk.path)
+	if err != nil {
+		return 0, fmt.Errorf("error al abrir disco: %v", err)
+	}
+	defer image.Close()
+
+	if _, idx := mbr.GetPartitionByName(fdisk.name); idx != -1 {
+		partition := &mbr.Mbr_partitions[idx]
+		if isMountedPartition(fdisk.path, partition.Part_id) {
+			return 0, errors.New("la partición está montada, desmonte primero")
 		}
-		if currentEBR.Part_next == -1 {
-			break
+
+		// El límite es el final del hueco libre que sigue a la partición
+		end := partition.Part_start + partition.Part_size
+		limit := end + structures.FollowingFree(mbr.FreeSegments(), end)
+
+		// Lo mínimo que puede medir es lo que ocupan sus lógicas o su sistema de archivos
+		used := formattedSize(image, partition.Part_start)
+		if partition.Part_type[0] == 'E' {
+			chain, err := readLogicalPartitions(image, partition)
+			if err != nil {
+				return 0, err
+			}
+			used = 0
+			for _, logical := range chain {
+				end := max(logical.ebr.Part_start+logical.ebr.Part_size, int32(logical.offset)+int32(binary.Size(logical.ebr)))
+				used = max(used, end-partition.Part_start)
+			}
 		}
-		currentOffset = int64(currentEBR.Part_next)
-		if err := currentEBR.Deserialize(file, currentOffset); err != nil {
-			return fmt.Errorf("error al leer EBR: %v", err)
+
+		newSize, err := resizePartition(partition.Part_start, partition.Part_size, delta, limit, used)
+		if err != nil {
+			return 0, err
+		}
+		partition.Part_size = newSize
+		if err := mbr.Serialize(fdisk.path); err != nil {
+			return 0, fmt.Errorf("error al serializar MBR: %v", err)
 		}
+		return newSize, nil
 	}
 
-	// Crear nuevo EBR
-	ebrSize := int(binary.Size(structures.EBR{}))
-	nextStart := currentOffset + int64(currentEBR.Part_size) // Ajustar para empezar después de la partición anterior
-	availableSpace = int(extPartition.Part_size) - int(nextStart-startExt) - ebrSize
-
-	if sizeBytes+ebrSize > availableSpace {
-		return errors.New("no hay espacio suficiente en la partición extendida")
+	ext, _ := extendedPartition(&mbr)
+	if ext == nil {
+		return 0, fmt.Errorf("la partición %s no existe en el disco", fdisk.name)
+	}
+	chain, err := readLogicalPartitions(image, ext)
+	if err != nil {
+		return 0, err
+	}
+	i := findLogicalPartition(chain, fdisk.name)
+	if i == -1 {
+		return 0, fmt.Errorf("la partición %s no existe en el disco", fdisk.name)
+	}
+	logical := chain[i]
+	if isMountedPartition(fdisk.path, logical.ebr.Part_id) {
+		return 0, errors.New("la partición está montada, desmonte primero")
 	}
 
-	newEBR := structures.EBR{
-		Part_status: [1]byte{'0'},
-		Part_fit:    [1]byte{fdisk.fit[0]},
-		Part_start:  int32(nextStart),
-		Part_size:   int32(sizeBytes),
-		Part_next:   -1,
+	// El límite es el final del hueco libre que sigue a la lógica dentro de la extendida
+	end := logical.ebr.Part_start + logical.ebr.Part_size
+	limit := end + structures.FollowingFree(logicalFreeSegments(ext, chain), end)
+	// El sistema de archivos de una lógica empieza después de su EBR (ver stores.findPartitionRange)
+	ebrSize := int32(binary.Size(logical.ebr))
+	used := formattedSize(image, logical.ebr.Part_start+ebrSize)
+	if used > 0 {
+		used += ebrSize
 	}
-	copy(newEBR.Part_name[:], fdisk.name)
 
-	currentEBR.Part_next = int32(nextStart)
-	if err := currentEBR.Serialize(file, currentOffset); err != nil {
-		return fmt.Errorf("error al actualizar EBR anterior: %v", err)
+	newSize, err := resizePartition(logical.ebr.Part_start, logical.ebr.Part_size, delta, limit, used)
+	if err != nil {
+		return 0, err
 	}
-	if err := newEBR.Serialize(file, int64(newEBR.Part_start)); err != nil {
-		return fmt.Errorf("error al crear nuevo EBR: %v", err)
+	logical.ebr.Part_size = newSize
+	if err := logical.ebr.Serialize(image, logical.offset); err != nil {
+		return 0, fmt.Errorf("error al serializar EBR: %v", err)
 	}
+	return newSize, nil
+}
 
-	return nil
+// resizePartition calcula el nuevo tamaño de una partición que inicia en start,
+// validando que no pase de limit ni quede por debajo de used
+func resizePartition(start, size int32, delta int, limit, used int32) (int32, error) {
+	newSize := int64(size) + int64(delta)
+	if newSize <= 0 {
+		return 0, fmt.Errorf("no se pueden quitar %d bytes a una partición de %d bytes", -delta, size)
+	}
+	if int64(start)+newSize > int64(limit) {
+		return 0, fmt.Errorf("no hay espacio libre suficiente después de la partición (disponible: %d bytes)", limit-start-size)
+	}
+	if newSize < int64(used) {
+		return 0, fmt.Errorf("la partición no puede medir menos de %d bytes, que es lo que está en uso", used)
+	}
+	return int32(newSize), nil
 }
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// FIND estructura que representa el comando find con sus parámetros
+type FIND struct {
+	path string // Carpeta donde empieza la búsqueda
+	name string // Patrón del nombre, admite ? y *
+}
+
+/*
+   find -path=/ -name="*.txt"
+   find -path=/home -name=?.*
+*/
+
+func ParseFind(ctx context.Context, tokens []string) (string, error) {
+	cmd := &FIND{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-path":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -path: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("la ruta debe ser absoluta (comenzar con /)")
+			}
+			cmd.path = value
+		case "-name":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -name: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if value == "" {
+				return "", errors.New("el nombre no puede estar vacío")
+			}
+			cmd.name = value
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.path == "" || cmd.name == "" {
+		return "", errors.New("faltan parámetros requeridos: -path, -name")
+	}
+
+	result, err := commandFind(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al buscar: %v", err)
+	}
+
+	return result, nil
+}
+
+func commandFind(ctx context.Context, find *FIND) (string, error) {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return "", errors.New("debe iniciar sesión primero")
+	}
+
+	sb, _, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	parentDirs, name := utils.GetParentDirectories(find.path)
+	_, start, err := ensureDirectory(session, sb, disk, append(parentDirs, name), false)
+	if err != nil {
+		return "", fmt.Errorf("ruta %s inválida: %w", find.path, err)
+	}
+	if err := checkPermission(session, start, permRead, find.path); err != nil {
+		return "", err
+	}
+
+	lines, err := findMatches(session, sb, disk, start, find.name, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("FIND: no se encontraron coincidencias para %s en %s", find.name, find.path), nil
+	}
+
+	var output strings.Builder
+	output.WriteString(find.path + "\n")
+	for _, line := range lines {
+		output.WriteString(line + "\n")
+	}
+	return strings.TrimSuffix(output.String(), "\n"), nil
+}
+
+// findMatches devuelve las líneas del árbol bajo dir que llevan a una coincidencia, indentadas según depth.
+// Solo desciende a carpetas que el usuario de la sesión puede leer.
+func findMatches(session *stores.Session, sb *structures.SuperBlock, disk *structures.Disk, dir *structures.Inode, pattern string, depth int) ([]string, error) {
+	entries, err := sb.ReadDirEntries(disk, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	indent := strings.Repeat("  ", depth)
+	var lines []string
+	for _, content := range entries {
+		name := content.Name()
+		if name == "." || name == ".." {
+			continue
+		}
+		child := &structures.Inode{}
+		err := child.Deserialize(disk, sb.InodeOffset(content.B_inodo))
+		if err != nil {
+			return nil, fmt.Errorf("error al leer inodo %d: %v", content.B_inodo, err)
+		}
+
+		var childLines []string
+		if child.I_type[0] == '0' && hasPermission(session, child, permRead) {
+			childLines, err = findMatches(session, sb, disk, child, pattern, depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Se muestra la entrada si coincide o si alguna coincidencia cuelga de ella
+		if utils.MatchWildcard(pattern, name) || len(childLines) > 0 {
+			if child.I_type[0] == '0' {
+				name += "/"
+			}
+			lines = append(lines, indent+name)
+			lines = append(lines, childLines...)
+		}
+	}
+	return lines, nil
+}
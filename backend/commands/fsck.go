@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+)
+
+// FSCK estructura que representa el comando fsck con sus parámetros
+type FSCK struct {
+	id     string // ID de la partición
+	repair bool   // Si se deben corregir los problemas encontrados
+}
+
+/*
+   fsck -id=671A
+   fsck -id=671A -repair
+*/
+
+func ParseFsck(tokens []string) (string, error) {
+	cmd := &FSCK{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-id":
+			if len(parts) != 2 || parts[1] == "" {
+				return "", fmt.Errorf("formato inválido para -id: %s", token)
+			}
+			cmd.id = parts[1]
+		case "-repair":
+			if len(parts) == 2 {
+				return "", errors.New("el parámetro -repair no recibe valor")
+			}
+			cmd.repair = true
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.id == "" {
+		return "", errors.New("faltan parámetros requeridos: -id")
+	}
+
+	output, err := commandFsck(cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al verificar la partición: %v", err)
+	}
+
+	return output, nil
+}
+
+func commandFsck(fsck *FSCK) (string, error) {
+	sb, mountedPartition, disk, err := stores.GetMountedPartitionSuperblock(fsck.id)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+	if sb.S_magic != 0xEF53 {
+		return "", errors.New("la partición no está formateada")
+	}
+
+	result, err := sb.Check(disk, fsck.repair)
+	if err != nil {
+		return "", err
+	}
+
+	// Los contadores y cursores corregidos quedan en el superbloque
+	if fsck.repair && len(result.Issues) > 0 {
+		if err := sb.Serialize(disk, int64(mountedPartition.Part_start)); err != nil {
+			return "", fmt.Errorf("error al escribir el superbloque: %v", err)
+		}
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("FSCK: %s, %d carpetas y %d archivos, %d inodos y %d bloques en uso\n",
+		fsck.id, result.Directories, result.Files, result.UsedInodes, result.UsedBlocks))
+	if len(result.Issues) == 0 {
+		output.WriteString("Sin problemas encontrados")
+		return output.String(), nil
+	}
+
+	for _, issue := range result.Issues {
+		status := ""
+		if issue.Repaired {
+			status = " [reparado]"
+		}
+		output.WriteString(fmt.Sprintf("  %s%s\n", issue.Description, status))
+	}
+	output.WriteString(fmt.Sprintf("%d problemas encontrados, %d sin reparar", len(result.Issues), result.Unrepaired()))
+	if !fsck.repair {
+		output.WriteString("; use -repair para corregirlos")
+	}
+	return output.String(), nil
+}
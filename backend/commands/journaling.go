@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+)
+
+// JOURNALING estructura que representa el comando journaling con sus parámetros
+type JOURNALING struct {
+	id string // ID de la partición
+}
+
+/*
+   journaling -id=671A
+*/
+
+func ParseJournaling(tokens []string) (string, error) {
+	cmd := &JOURNALING{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-id":
+			if len(parts) != 2 || parts[1] == "" {
+				return "", fmt.Errorf("formato inválido para -id: %s", token)
+			}
+			cmd.id = parts[1]
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.id == "" {
+		return "", errors.New("faltan parámetros requeridos: -id")
+	}
+
+	output, err := commandJournaling(cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al leer el journal: %v", err)
+	}
+
+	return output, nil
+}
+
+func commandJournaling(journaling *JOURNALING) (string, error) {
+	sb, _, disk, err := stores.GetMountedPartitionSuperblock(journaling.id)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	entries, err := sb.ReadJournal(disk)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return fmt.Sprintf("JOURNALING: el journal de %s está vacío", journaling.id), nil
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("JOURNALING: %d entradas en %s\n", len(entries), journaling.id))
+	output.WriteString(fmt.Sprintf("%-5s %-10s %-30s %-30s %s\n", "#", "Operación", "Ruta", "Contenido", "Fecha"))
+	for _, entry := range entries {
+		date := time.Unix(int64(entry.J_content.I_date), 0).Format("02/01/2006 15:04:05")
+		output.WriteString(fmt.Sprintf("%-5d %-10s %-30s %-30s %s\n", entry.J_count, entry.Operation(), orDash(entry.Path()), orDash(entry.Content()), date))
+	}
+	return strings.TrimSuffix(output.String(), "\n"), nil
+}
+
+// RecordJournal registra en el journal de la partición de la sesión una operación que ya se ejecutó.
+// En EXT2 no hace nada.
+func RecordJournal(ctx context.Context, operation string, tokens []string) error {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return nil
+	}
+	sb, _, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return err
+	}
+
+	uid, _ := strconv.Atoi(session.UID)
+	gid, _ := strconv.Atoi(session.GID)
+	itemPath, content := journalEntry(tokens)
+	return sb.AppendJournal(disk, operation, itemPath, content, int32(uid), int32(gid))
+}
+
+// PrepareJournal prepara, antes de ejecutar una operación, los parámetros con los que se ejecuta y
+// queda en el journal de la partición de la sesión:
+//   - mkusr recibe el hash de la contraseña (-hash) en lugar de -pass, para no guardarla en texto plano.
+//   - edit recibe el contenido (-contenido64) en lugar de la ruta del anfitrión, para que recovery
+//     no dependa de un archivo que puede cambiar.
+//   - Las operaciones que no caben en el journal, o que llegan con el journal lleno, no se ejecutan,
+//     porque recovery no podría repetirlas.
+//
+// En EXT2 devuelve los parámetros sin cambios.
+func PrepareJournal(ctx context.Context, operation string, tokens []string) ([]string, error) {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return tokens, nil // El comando fallará por falta de sesión
+	}
+	sb, _, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil || !sb.IsExt3() {
+		return tokens, nil
+	}
+	if err := sb.CheckJournalSpace(disk); err != nil {
+		return nil, err
+	}
+
+	switch operation {
+	case "mkusr":
+		if tokens, err = hashMkusrPassword(tokens); err != nil {
+			return nil, err
+		}
+	case "edit":
+		tokens = inlineEditContent(tokens)
+	}
+
+	itemPath, content := journalEntry(tokens)
+	if err := sb.CheckJournalEntry(operation, itemPath, content); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// journalEntry devuelve la ruta y el contenido de la entrada del journal de una operación:
+// el valor de -path como ruta y el resto de parámetros como contenido.
+func journalEntry(tokens []string) (string, string) {
+	itemPath := ""
+	var params []string
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		if strings.ToLower(parts[0]) == "-path" && len(parts) == 2 {
+			itemPath = parts[1]
+			continue
+		}
+		// Volver a poner comillas a los valores con espacios para poder repetir el comando
+		if len(parts) == 2 && strings.Contains(parts[1], " ") {
+			token = fmt.Sprintf("%s=\"%s\"", parts[0], parts[1])
+		}
+		params = append(params, token)
+	}
+	return itemPath, strings.Join(params, " ")
+}
+
+// orDash devuelve - si la cadena está vacía
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -1,14 +1,14 @@
 package commands
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"os"
+	"strconv"
 	"strings"
 
+	accounts "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/accounts"
 	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
-	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
 )
 
 // LOGIN estructura que representa el comando login con sus parámetros
@@ -19,7 +19,7 @@ type LOGIN struct {
 }
 
 // ParseLogin parsea los tokens del comando login
-func ParseLogin(tokens []string) (string, error) {
+func ParseLogin(ctx context.Context, tokens []string) (string, error) {
 	cmd := &LOGIN{}
 
 	for _, token := range tokens {
@@ -55,7 +55,7 @@ func ParseLogin(tokens []string) (string, error) {
 		return "", errors.New("faltan parámetros requeridos: -user, -pass, -id")
 	}
 
-	err := commandLogin(cmd)
+	err := commandLogin(ctx, cmd)
 	if err != nil {
 		return "", fmt.Errorf("error al iniciar sesión: %v", err)
 	}
@@ -63,109 +63,68 @@ func ParseLogin(tokens []string) (string, error) {
 	return fmt.Sprintf("LOGIN: Sesión iniciada como %s en %s", cmd.user, cmd.id), nil
 }
 
-func commandLogin(login *LOGIN) error {
-	if stores.CurrentSession.ID != "" {
+func commandLogin(ctx context.Context, login *LOGIN) error {
+	if stores.SessionFromContext(ctx).ID != "" {
 		return errors.New("ya hay una sesión activa, cierre la sesión actual primero")
 	}
 
-	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(login.id)
+	partitionSuperblock, _, partitionDisk, err := stores.GetMountedPartitionSuperblock(login.id)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0644)
+	users, err := accounts.Load(partitionSuperblock, partitionDisk)
 	if err != nil {
-		return fmt.Errorf("error al abrir disco: %v", err)
+		return err
 	}
-	defer file.Close()
-
-	// Leer el inodo raíz (inodo 0)
-	rootInode := &structures.Inode{}
-	err = rootInode.Deserialize(partitionPath, int64(partitionSuperblock.S_inode_start))
-	if err != nil {
-		return fmt.Errorf("error al leer el inodo raíz: %w", err)
-	}
-	if rootInode.I_type[0] != '0' {
-		return errors.New("el inodo raíz no es una carpeta válida")
+	user := users.User(login.user)
+	if user == nil || !user.CheckPassword(login.pass) {
+		return errors.New("usuario o contraseña incorrectos")
 	}
 
-	// Buscar users.txt en el bloque raíz
-	var usersInodeNum int32 = -1
-	for _, blockNum := range rootInode.I_block[:12] {
-		if blockNum == -1 {
-			break
-		}
-		folderBlock := &structures.FolderBlock{}
-		err = folderBlock.Deserialize(partitionPath, int64(partitionSuperblock.S_block_start+blockNum*partitionSuperblock.S_block_size))
-		if err != nil {
-			return fmt.Errorf("error al leer el bloque %d de la raíz: %w", blockNum, err)
-		}
-		for _, content := range folderBlock.B_content {
-			name := strings.Trim(string(content.B_name[:]), "\x00")
-			if name == "users.txt" {
-				usersInodeNum = content.B_inodo
-				break
-			}
-		}
-		if usersInodeNum != -1 {
-			break
+	// Los discos con contraseñas en texto plano se migran al primer inicio de sesión
+	if users.Version < 2 {
+		if err := accounts.Save(partitionSuperblock, partitionDisk, users); err != nil {
+			return fmt.Errorf("error al migrar users.txt: %w", err)
 		}
 	}
-	if usersInodeNum == -1 {
-		return errors.New("users.txt no encontrado en el directorio raíz")
+
+	gid, groups, err := sessionGroups(users, user)
+	if err != nil {
+		return err
 	}
 
-	// Leer el inodo de users.txt
-	usersInode := &structures.Inode{}
-	err = usersInode.Deserialize(partitionPath, int64(partitionSuperblock.S_inode_start+usersInodeNum*partitionSuperblock.S_inode_size))
+	return stores.SetSession(ctx, stores.Session{
+		ID:       login.id,
+		Username: login.user,
+		UID:      strconv.Itoa(int(user.ID)),
+		GID:      gid,
+		Groups:   groups,
+	})
+}
+
+// sessionGroups devuelve el GID del grupo principal del usuario y los de sus grupos adicionales,
+// como los guarda stores.Session
+func sessionGroups(users *accounts.File, user *accounts.User) (string, []string, error) {
+	gid, extra, err := users.GroupIDs(user)
 	if err != nil {
-		return fmt.Errorf("error al leer el inodo de users.txt: %w", err)
+		return "", nil, err
 	}
-	if usersInode.I_type[0] != '1' {
-		return errors.New("users.txt no es un archivo válido")
+	groups := make([]string, len(extra))
+	for i, id := range extra {
+		groups[i] = strconv.Itoa(int(id))
 	}
+	return strconv.Itoa(int(gid)), groups, nil
+}
 
-	// Leer todos los bloques del inodo
-	var content strings.Builder
-	for i, blockNum := range usersInode.I_block[:12] {
-		if blockNum == -1 {
-			break
-		}
-		fileBlock := &structures.FileBlock{}
-		err = fileBlock.Deserialize(partitionPath, int64(partitionSuperblock.S_block_start+blockNum*partitionSuperblock.S_block_size))
-		if err != nil {
-			return fmt.Errorf("error al leer el bloque %d de users.txt: %w", blockNum, err)
-		}
-		content.Write(bytes.Trim(fileBlock.B_content[:], "\x00"))
-		fmt.Printf("DEBUG: Bloque %d leído: %s\n", i, strings.Trim(string(fileBlock.B_content[:]), "\x00"))
+// refreshUserSessions actualiza los grupos de las sesiones abiertas del usuario name en la partición id
+// después de cambiar users.txt, para que los permisos no sigan usando los grupos anteriores
+func refreshUserSessions(id string, users *accounts.File, name string) {
+	user := users.User(name)
+	if user == nil {
+		return
 	}
-	usersContent := strings.TrimSpace(content.String())
-	fmt.Printf("DEBUG: Contenido de users.txt en login:\n%s\n", usersContent)
-
-	lines := strings.Split(usersContent, "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, ",")
-		if len(parts) < 3 {
-			continue
-		}
-
-		if len(parts) == 4 && parts[1] == "U" {
-			username := parts[2]
-			password := parts[3]
-			if username == login.user && password == login.pass {
-				stores.CurrentSession = stores.Session{
-					ID:       login.id,
-					Username: login.user,
-					UID:      parts[0],
-					GID:      parts[0],
-				}
-				return nil
-			}
-		}
+	if gid, groups, err := sessionGroups(users, user); err == nil {
+		stores.UpdateUserSessions(id, name, gid, groups)
 	}
-
-	return errors.New("usuario o contraseña incorrectos")
 }
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -11,14 +12,14 @@ import (
 type LOGOUT struct{}
 
 // ParseLogout parsea los tokens del comando logout
-func ParseLogout(tokens []string) (string, error) {
+func ParseLogout(ctx context.Context, tokens []string) (string, error) {
 	// Verificar que no haya parámetros adicionales
 	if len(tokens) > 0 {
 		return "", fmt.Errorf("el comando logout no acepta parámetros, solo 'logout'")
 	}
 
 	// Ejecutar el comando
-	err := commandLogout()
+	err := commandLogout(ctx)
 	if err != nil {
 		return "", fmt.Errorf("error al cerrar sesión: %v", err)
 	}
@@ -27,19 +28,20 @@ func ParseLogout(tokens []string) (string, error) {
 }
 
 // commandLogout implementa la lógica del comando logout
-func commandLogout() error {
+func commandLogout(ctx context.Context) error {
 	// Verificar si hay una sesión activa
-	if stores.CurrentSession.ID == "" {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
 		return errors.New("no hay ninguna sesión activa para cerrar")
 	}
 
-	// Limpiar la sesión
-	stores.CurrentSession = stores.Session{
-		ID:       "",
-		Username: "",
-		UID:      "",
-		GID:      "",
+	// Escribir en el disco lo que quedó pendiente en la caché
+	if _, err := stores.SyncMountedDisk(session.ID); err != nil {
+		return fmt.Errorf("error al sincronizar el disco: %v", err)
 	}
 
+	// Limpiar la sesión
+	stores.ClearSession(ctx)
+
 	return nil
 }
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+)
+
+// LOSS estructura que representa el comando loss con sus parámetros
+type LOSS struct {
+	id string // ID de la partición
+}
+
+/*
+   loss -id=671A
+*/
+
+func ParseLoss(tokens []string) (string, error) {
+	cmd := &LOSS{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-id":
+			if len(parts) != 2 || parts[1] == "" {
+				return "", fmt.Errorf("formato inválido para -id: %s", token)
+			}
+			cmd.id = parts[1]
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.id == "" {
+		return "", errors.New("faltan parámetros requeridos: -id")
+	}
+
+	err := commandLoss(cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al simular la pérdida: %v", err)
+	}
+
+	return fmt.Sprintf("LOSS: Bitmaps, inodos y bloques de %s borrados; use recovery para restaurarlos", cmd.id), nil
+}
+
+// commandLoss simula una falla borrando todo menos el superbloque y el journal
+func commandLoss(loss *LOSS) error {
+	sb, _, disk, err := stores.GetMountedPartitionSuperblock(loss.id)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+	if !sb.IsExt3() {
+		return errors.New("loss solo está disponible para particiones EXT3")
+	}
+
+	return sb.ClearFileSystem(disk)
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -21,7 +22,7 @@ type MKDIR struct {
    mkdir -path="/home/mis documentos/archivos clases"
 */
 
-func ParseMkdir(tokens []string) (string, error) {
+func ParseMkdir(ctx context.Context, tokens []string) (string, error) {
 	cmd := &MKDIR{}
 
 	// Procesar cada token
@@ -55,7 +56,7 @@ func ParseMkdir(tokens []string) (string, error) {
 	}
 
 	// Ejecutar el comando
-	err := commandMkdir(cmd)
+	err := commandMkdir(ctx, cmd)
 	if err != nil {
 		return "", fmt.Errorf("error al crear el directorio: %v", err)
 	}
@@ -64,20 +65,21 @@ func ParseMkdir(tokens []string) (string, error) {
 }
 
 // commandMkdir implementa la lógica para crear el directorio
-func commandMkdir(mkdir *MKDIR) error {
+func commandMkdir(ctx context.Context, mkdir *MKDIR) error {
 	// Verificar si hay una sesión activa
-	if stores.CurrentSession.ID == "" {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
 		return errors.New("debe iniciar sesión primero")
 	}
 
 	// Obtener la partición montada usando el ID de la sesión
-	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(stores.CurrentSession.ID)
+	partitionSuperblock, mountedPartition, partitionDisk, err := stores.GetMountedPartitionSuperblock(session.ID)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
 	// Crear el directorio
-	err = createDirectory(mkdir.path, partitionSuperblock, partitionPath, mountedPartition)
+	err = createDirectory(session, mkdir.path, partitionSuperblock, partitionDisk, mountedPartition)
 	if err != nil {
 		return err
 	}
@@ -86,17 +88,40 @@ func commandMkdir(mkdir *MKDIR) error {
 }
 
 // createDirectory crea el directorio en la partición
-func createDirectory(dirPath string, sb *structures.SuperBlock, partitionPath string, mountedPartition *structures.Partition) error {
+func createDirectory(session *stores.Session, dirPath string, sb *structures.SuperBlock, partitionDisk *structures.Disk, mountedPartition *structures.Partition) error {
 	parentDirs, destDir := utils.GetParentDirectories(dirPath)
+	if destDir == "" {
+		return errors.New("el directorio / ya existe")
+	}
+
+	// Navegar o crear directorios padres
+	parentNum, parent, err := ensureDirectory(session, sb, partitionDisk, parentDirs, true)
+	if err != nil {
+		return fmt.Errorf("error al crear el directorio: %w", err)
+	}
+	if err := checkPermission(session, parent, permWrite, joinPath(parentDirs)); err != nil {
+		return err
+	}
 
-	// Crear el directorio según el path proporcionado
-	err := sb.CreateFolder(partitionPath, parentDirs, destDir)
+	existing, err := sb.FindEntry(partitionDisk, parent, destDir)
+	if err != nil {
+		return err
+	}
+	if existing != -1 {
+		return fmt.Errorf("%s ya existe", dirPath)
+	}
+
+	uid, gid, err := sessionOwner(session)
+	if err != nil {
+		return err
+	}
+	_, err = sb.MakeFolder(partitionDisk, parentNum, parent, destDir, uid, gid, [3]byte{'6', '6', '4'})
 	if err != nil {
 		return fmt.Errorf("error al crear el directorio: %w", err)
 	}
 
 	// Serializar el superbloque
-	err = sb.Serialize(partitionPath, int64(mountedPartition.Part_start))
+	err = sb.Serialize(partitionDisk, int64(mountedPartition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error al serializar el superbloque: %w", err)
 	}
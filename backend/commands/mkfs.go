@@ -5,28 +5,35 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	accounts "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/accounts"
 	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"         // Paquete que contiene las estructuras de datos necesarias para el manejo de discos y particiones
 	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures" // Paquete que contiene las estructuras de datos necesarias para el manejo de discos y particiones
 )
 
 // MKFS estructura que representa el comando mkfs con sus parámetros
 type MKFS struct {
-	id  string // ID del disco
-	typ string // Tipo de formato (full)
-	fs  string // Tipo de sistema de archivos (2fs o 3fs)
+	id        string // ID del disco
+	typ       string // Tipo de formato (full)
+	fs        string // Tipo de sistema de archivos (2fs o 3fs)
+	bitmap    string // Formato de los bitmaps (bytes o packed)
+	blockSize int32  // Tamaño de bloque en bytes
+	ratio     int32  // Bloques por inodo
 }
 
 /*
    mkfs -id=vd1 -type=full
    mkfs -id=vd2
+   mkfs -id=vd3 -fs=3fs -bitmap=packed
+   mkfs -id=vd4 -blocksize=256 -inodes-ratio=5
 */
 
 func ParseMkfs(tokens []string) (string, error) {
-	cmd := &MKFS{typ: "full", fs: "2fs"}
+	cmd := &MKFS{typ: "full", fs: "2fs", bitmap: "bytes", blockSize: 64, ratio: 3}
 
 	for _, token := range tokens {
 		parts := strings.SplitN(token, "=", 2)
@@ -53,6 +60,24 @@ func ParseMkfs(tokens []string) (string, error) {
 				return "", errors.New("el fs debe ser 2fs o 3fs")
 			}
 			cmd.fs = value
+		case "-bitmap":
+			value = strings.ToLower(value)
+			if value != "bytes" && value != "packed" {
+				return "", errors.New("el bitmap debe ser bytes o packed")
+			}
+			cmd.bitmap = value
+		case "-blocksize":
+			size, err := strconv.Atoi(value)
+			if err != nil || !slices.Contains(validBlockSizes, int32(size)) {
+				return "", errors.New("el tamaño de bloque debe ser 64, 128, 256, 512 o 1024")
+			}
+			cmd.blockSize = int32(size)
+		case "-inodes-ratio":
+			ratio, err := strconv.Atoi(value)
+			if err != nil || ratio < 1 || ratio > 64 {
+				return "", errors.New("la proporción de bloques por inodo debe ser un entero entre 1 y 64")
+			}
+			cmd.ratio = int32(ratio)
 		default:
 			return "", fmt.Errorf("parámetro desconocido: %s", key)
 		}
@@ -71,115 +96,91 @@ func ParseMkfs(tokens []string) (string, error) {
 }
 
 func commandMkfs(mkfs *MKFS) error {
-	partitionPath, exists := stores.MountedPartitions[mkfs.id]
-	if !exists {
-		return errors.New("partición no montada")
-	}
-
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0644)
+	disk, err := stores.GetMountedDisk(mkfs.id)
 	if err != nil {
-		return fmt.Errorf("error al abrir disco: %v", err)
-	}
-	defer file.Close()
-
-	var mbr structures.MBR
-	if err := mbr.Deserialize(partitionPath); err != nil {
-		return fmt.Errorf("error al deserializar MBR: %v", err)
-	}
-
-	var partition *structures.Partition
-	var startOffset int64
-	var partitionSize int32
-	for _, p := range mbr.Mbr_partitions {
-		partID := strings.Trim(string(p.Part_id[:]), "\x00")
-		if partID == mkfs.id {
-			partition = &p
-			startOffset = int64(p.Part_start)
-			partitionSize = p.Part_size
-			break
-		}
-	}
-
-	if partition == nil {
-		var extPartition *structures.Partition
-		for _, p := range mbr.Mbr_partitions {
-			if p.Part_type[0] == 'E' && p.Part_status[0] != 'N' {
-				extPartition = &p
-				break
-			}
-		}
-		if extPartition == nil {
-			return fmt.Errorf("partición %s no encontrada en el disco", mkfs.id)
-		}
-
-		var currentEBR structures.EBR
-		currentOffset := int64(extPartition.Part_start)
-		for {
-			if err := currentEBR.Deserialize(file, currentOffset); err != nil {
-				return fmt.Errorf("error al leer EBR: %v", err)
-			}
-			partID := strings.Trim(string(currentEBR.Part_id[:]), "\x00")
-			if partID == mkfs.id {
-				startOffset = int64(currentEBR.Part_start)
-				partitionSize = currentEBR.Part_size
-				break
-			}
-			if currentEBR.Part_next == -1 {
-				return fmt.Errorf("partición lógica %s no encontrada", mkfs.id)
-			}
-			currentOffset = int64(currentEBR.Part_next)
-		}
+		return err
 	}
+	startOffset := disk.Start()
+	partitionSize := int32(disk.Size())
 
 	var sbCheck structures.SuperBlock
-	if err := sbCheck.Deserialize(partitionPath, startOffset); err == nil && sbCheck.S_magic == 0xEF53 {
+	if err := sbCheck.Deserialize(disk, startOffset); err == nil && sbCheck.S_magic == 0xEF53 {
 		return errors.New("la partición ya está formateada")
 	}
 
-	n := calculateN(partitionSize)
+	n := calculateN(partitionSize, mkfs)
 	fmt.Printf("DEBUG: partitionSize=%d, n=%d\n", partitionSize, n)
-	superBlock := createSuperBlock(startOffset, n, mkfs.fs)
+	superBlock := createSuperBlock(startOffset, n, mkfs)
 
-	// Crear bitmaps y users.txt
-	if err := superBlock.CreateBitMaps(file); err != nil {
+	// Crear journal (solo EXT3), bitmaps y users.txt
+	if err := superBlock.CreateJournal(disk); err != nil {
+		return err
+	}
+	if err := superBlock.CreateBitMaps(disk); err != nil {
+		return err
+	}
+	users, err := accounts.New()
+	if err != nil {
 		return err
 	}
-	if err := superBlock.CreateUsersFile(partitionPath); err != nil {
+	if err := superBlock.CreateUsersFile(disk, users.String()); err != nil {
 		return err
 	}
-	if err := superBlock.Serialize(partitionPath, startOffset); err != nil {
+	if err := superBlock.Serialize(disk, startOffset); err != nil {
 		return err
 	}
 
 	return nil
 }
-func calculateN(size int32) int32 {
-	numerator := int(size) - binary.Size(structures.SuperBlock{})
-	denominator := 4 + binary.Size(structures.Inode{}) + 3*binary.Size(structures.FileBlock{})
-	return int32(math.Floor(float64(numerator) / float64(denominator)))
-}
 
-func createSuperBlock(startOffset int64, n int32, fs string) *structures.SuperBlock {
-	bm_inode_start := int32(startOffset) + int32(binary.Size(structures.SuperBlock{}))
-	bm_block_start := bm_inode_start + n
-	inode_start := bm_block_start + (3 * n)
-	block_start := inode_start + (int32(binary.Size(structures.Inode{})) * n)
+// validBlockSizes son los tamaños de bloque que acepta -blocksize
+var validBlockSizes = []int32{64, 128, 256, 512, 1024}
 
+// calculateN calcula la cantidad de inodos; cada inodo lleva ratio bloques y, en EXT3, una entrada del journal.
+// Cada inodo ocupa 1+ratio entradas de bitmap: un byte por entrada, o un bit si están empaquetadas.
+func calculateN(size int32, mkfs *MKFS) int32 {
+	numerator := float64(int(size) - binary.Size(structures.SuperBlock{}))
+	denominator := float64(binary.Size(structures.Inode{}) + int(mkfs.ratio*mkfs.blockSize))
+	if mkfs.fs == "3fs" {
+		denominator += float64(binary.Size(structures.Journal{}))
+	}
+	if mkfs.bitmap == "packed" {
+		numerator -= 2 // Cada bitmap puede terminar en un byte incompleto
+		denominator += float64(1+mkfs.ratio) / 8
+	} else {
+		denominator += float64(1 + mkfs.ratio)
+	}
+	return int32(math.Floor(numerator / denominator))
+}
+
+func createSuperBlock(startOffset int64, n int32, mkfs *MKFS) *structures.SuperBlock {
 	fsType := int32(2)
-	if fs == "3fs" {
+	journalSize := int32(0)
+	if mkfs.fs == "3fs" {
 		fsType = 3
+		journalSize = int32(binary.Size(structures.Journal{})) * n
+	}
+	packed := mkfs.bitmap == "packed"
+	if packed {
+		fsType |= structures.FlagPackedBitmaps
 	}
 
+	// En EXT3 el journal ocupa el espacio entre el superbloque y el bitmap de inodos
+	bm_inode_start := int32(startOffset) + int32(binary.Size(structures.SuperBlock{})) + journalSize
+	bm_block_start := bm_inode_start + structures.BitmapSize(n, packed)
+	inode_start := bm_block_start + structures.BitmapSize(mkfs.ratio*n, packed)
+	block_start := inode_start + (int32(binary.Size(structures.Inode{})) * n)
+
 	totalInodes := n
-	totalBlocks := 3 * n
+	totalBlocks := mkfs.ratio * n
 	freeInodes := n - 2 // Raíz y users.txt
-	freeBlocks := 3*n - 2
+	freeBlocks := mkfs.ratio*n - 2
 
 	if n < 2 {
 		totalInodes = 2
-		totalBlocks = 6
+		totalBlocks = 2 * mkfs.ratio
 		freeInodes = 0
-		freeBlocks = 4
+		freeBlocks = 2*mkfs.ratio - 2
 	}
 
 	return &structures.SuperBlock{
@@ -193,7 +194,7 @@ func createSuperBlock(startOffset int64, n int32, fs string) *structures.SuperBl
 		S_mnt_count:         1,
 		S_magic:             0xEF53,
 		S_inode_size:        int32(binary.Size(structures.Inode{})),
-		S_block_size:        int32(binary.Size(structures.FileBlock{})),
+		S_block_size:        mkfs.blockSize,
 		S_first_ino:         2, // Próximo inodo libre
 		S_first_blo:         2, // Próximo bloque libre
 		S_bm_inode_start:    bm_inode_start,
@@ -3,7 +3,7 @@ package commands
 import (
 	"errors" // Paquete para manejar errores y crear nuevos errores con mensajes personalizados
 	"fmt"    // Paquete para formatear cadenas y realizar operaciones de entrada/salida
-	"os"     // Paquete para trabajar con expresiones regulares, útil para encontrar y manipular patrones en cadenas
+	"time"
 
 	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
 	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures" // Paquete que contiene las estructuras de datos necesarias para el manejo de discos y particiones
@@ -78,11 +78,11 @@ func commandMount(mount *MOUNT) (string, error) {
 	partition, idx := mbr.GetPartitionByName(mount.name)
 	if partition == nil {
 		// Buscar en lógicas
-		file, err := os.OpenFile(mount.path, os.O_RDWR, 0644)
+		image, err := structures.OpenImage(mount.path)
 		if err != nil {
 			return "", fmt.Errorf("error al abrir disco: %v", err)
 		}
-		defer file.Close()
+		defer image.Close()
 
 		var extPartition *structures.Partition
 		for _, p := range mbr.Mbr_partitions {
@@ -97,7 +97,7 @@ func commandMount(mount *MOUNT) (string, error) {
 
 		startExt := int64(extPartition.Part_start)
 		var currentEBR structures.EBR
-		err = currentEBR.Deserialize(file, startExt)
+		err = currentEBR.Deserialize(image, startExt)
 		if err != nil || currentEBR.Part_status[0] == 0 || currentEBR.Part_status[0] == 'N' {
 			return "", fmt.Errorf("la partición %s no existe en el disco", mount.name)
 		}
@@ -107,7 +107,12 @@ func commandMount(mount *MOUNT) (string, error) {
 			ebName := strings.Trim(string(currentEBR.Part_name[:]), "\x00")
 			if ebName == mount.name {
 				if currentEBR.Part_status[0] == '1' {
-					return "", errors.New("la partición lógica ya está montada")
+					// El disco puede seguir marcado como montado de una ejecución anterior
+					id := strings.Trim(string(currentEBR.Part_id[:]), "\x00")
+					if path, _ := stores.MountedPath(id); path == mount.path || !stores.RestoreMount(mount.path, id) {
+						return "", errors.New("la partición lógica ya está montada")
+					}
+					return id, finishMount(id)
 				}
 				// Generar ID usando utils
 				letter, correlative, err := utils.GetLetterAndPartitionCorrelative(mount.path)
@@ -117,17 +122,17 @@ func commandMount(mount *MOUNT) (string, error) {
 				id := fmt.Sprintf("%s%d%s", stores.Carnet, correlative, letter)
 				currentEBR.Part_status = [1]byte{'1'}
 				copy(currentEBR.Part_id[:], id)
-				if err := currentEBR.Serialize(file, currentOffset); err != nil {
+				if err := currentEBR.Serialize(image, currentOffset); err != nil {
 					return "", fmt.Errorf("error al serializar EBR: %v", err)
 				}
-				stores.MountedPartitions[id] = mount.path
-				return id, nil
+				stores.AddMount(id, mount.path)
+				return id, finishMount(id)
 			}
 			if currentEBR.Part_next == -1 {
 				break
 			}
 			currentOffset = int64(currentEBR.Part_next)
-			if err := currentEBR.Deserialize(file, currentOffset); err != nil {
+			if err := currentEBR.Deserialize(image, currentOffset); err != nil {
 				return "", fmt.Errorf("error al leer EBR: %v", err)
 			}
 		}
@@ -136,7 +141,12 @@ func commandMount(mount *MOUNT) (string, error) {
 
 	// Partición primaria encontrada
 	if partition.Part_status[0] == '1' {
-		return "", errors.New("la partición ya está montada")
+		// El disco puede seguir marcado como montado de una ejecución anterior
+		id := strings.Trim(string(partition.Part_id[:]), "\x00")
+		if path, _ := stores.MountedPath(id); path == mount.path || !stores.RestoreMount(mount.path, id) {
+			return "", errors.New("la partición ya está montada")
+		}
+		return id, finishMount(id)
 	}
 	if partition.Part_type[0] == 'E' {
 		return "", errors.New("no se pueden montar particiones extendidas")
@@ -151,9 +161,39 @@ func commandMount(mount *MOUNT) (string, error) {
 
 	partition.MountPartition(correlative, id)
 	mbr.Mbr_partitions[idx] = *partition
-	stores.MountedPartitions[id] = mount.path
+	stores.AddMount(id, mount.path)
 	if err := mbr.Serialize(mount.path); err != nil {
 		return "", fmt.Errorf("error al serializar MBR: %v", err)
 	}
-	return id, nil
+	return id, finishMount(id)
+}
+
+// finishMount actualiza el superbloque de la partición montada y guarda el estado de montaje
+func finishMount(id string) error {
+	disk, err := stores.GetMountedDisk(id)
+	if err != nil {
+		return err
+	}
+	if err := recordMount(disk); err != nil {
+		return err
+	}
+	if err := stores.SaveState(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// recordMount incrementa el contador de montajes y actualiza la hora de montaje
+// del superbloque, si la partición ya fue formateada
+func recordMount(disk *structures.Disk) error {
+	var sb structures.SuperBlock
+	if err := sb.Deserialize(disk, disk.Start()); err != nil || sb.S_magic != 0xEF53 {
+		return nil
+	}
+	sb.S_mnt_count++
+	sb.S_mtime = float32(time.Now().Unix())
+	if err := sb.Serialize(disk, disk.Start()); err != nil {
+		return fmt.Errorf("error al actualizar el superbloque: %v", err)
+	}
+	return nil
 }
@@ -27,13 +27,14 @@ func ParseMounted(tokens []string) (string, error) {
 }
 
 func commandMounted() (string, error) {
-	if len(stores.MountedPartitions) == 0 {
+	mounted := stores.MountedPartitions()
+	if len(mounted) == 0 {
 		return "MOUNTED: No hay particiones montadas actualmente", nil
 	}
 
 	var output strings.Builder
 	output.WriteString("MOUNTED: Particiones montadas:\n")
-	for id, path := range stores.MountedPartitions {
+	for id, path := range mounted {
 		output.WriteString(fmt.Sprintf("  ID: %s  Path: %s\n", id, path))
 	}
 	return output.String(), nil
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// MOVE estructura que representa el comando move con sus parámetros
+type MOVE struct {
+	path    string // Ruta del archivo o carpeta a mover
+	destino string // Carpeta a la que se mueve
+}
+
+/*
+   move -path=/home/user/docs -destino=/home/images
+*/
+
+func ParseMove(ctx context.Context, tokens []string) (string, error) {
+	cmd := &MOVE{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-path":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -path: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("la ruta debe ser absoluta (comenzar con /)")
+			}
+			cmd.path = value
+		case "-destino":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -destino: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("el destino debe ser una ruta absoluta (comenzar con /)")
+			}
+			cmd.destino = value
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.path == "" || cmd.destino == "" {
+		return "", errors.New("faltan parámetros requeridos: -path, -destino")
+	}
+
+	err := commandMove(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al mover: %v", err)
+	}
+
+	return fmt.Sprintf("MOVE: %s movido a %s correctamente", cmd.path, cmd.destino), nil
+}
+
+func commandMove(ctx context.Context, mv *MOVE) error {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return errors.New("debe iniciar sesión primero")
+	}
+
+	sb, mountedPartition, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	parentDirs, name := utils.GetParentDirectories(mv.path)
+	if name == "" {
+		return errors.New("no se puede mover la raíz")
+	}
+	if len(parentDirs) == 0 && name == "users.txt" {
+		return errors.New("no se puede mover /users.txt")
+	}
+	_, parent, err := ensureDirectory(session, sb, disk, parentDirs, false)
+	if err != nil {
+		return fmt.Errorf("directorio padre inválido: %w", err)
+	}
+	srcNum, src, err := resolvePath(session, sb, disk, append(parentDirs, name))
+	if err != nil {
+		return fmt.Errorf("%s no existe: %w", mv.path, err)
+	}
+	if err := checkPermission(session, src, permWrite, mv.path); err != nil {
+		return err
+	}
+	if err := checkPermission(session, parent, permWrite, joinPath(parentDirs)); err != nil {
+		return err
+	}
+
+	destDirs, destName := utils.GetParentDirectories(mv.destino)
+	destNum, dest, err := ensureDirectory(session, sb, disk, append(destDirs, destName), false)
+	if err != nil {
+		return fmt.Errorf("destino %s inválido: %w", mv.destino, err)
+	}
+	if err := checkPermission(session, dest, permWrite, mv.destino); err != nil {
+		return err
+	}
+
+	// Una carpeta no puede moverse dentro de sí misma
+	inside, err := sb.IsAncestor(disk, srcNum, destNum)
+	if err != nil {
+		return err
+	}
+	if inside {
+		return fmt.Errorf("no se puede mover %s dentro de sí mismo", mv.path)
+	}
+
+	existing, err := sb.FindEntry(disk, dest, name)
+	if err != nil {
+		return err
+	}
+	if existing != -1 {
+		return fmt.Errorf("%s ya existe en %s", name, mv.destino)
+	}
+
+	// Solo se reenlazan las entradas; el inodo y sus bloques no cambian
+	err = sb.AddEntry(disk, destNum, dest, name, srcNum)
+	if err != nil {
+		return err
+	}
+	_, err = sb.RemoveEntry(disk, parent, name)
+	if err != nil {
+		return err
+	}
+	if src.I_type[0] == '0' {
+		err = sb.SetParentEntry(disk, src, destNum)
+		if err != nil {
+			return err
+		}
+	}
+
+	// AddEntry puede haber reservado un bloque nuevo en el destino
+	err = sb.Serialize(disk, int64(mountedPartition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error al serializar el superbloque: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+)
+
+/*
+Capa de permisos de los comandos del sistema de archivos.
+
+Cada digito de I_perm (propietario, grupo, otros) combina lectura (4), escritura (2)
+y ejecución (1). El dígito de grupo aplica si el grupo del inodo es el grupo principal
+o uno de los grupos adicionales del usuario. Atravesar una carpeta requiere permiso de
+lectura sobre ella; crear, eliminar o renombrar entradas requiere permiso de escritura
+sobre la carpeta que las contiene. root no pasa por ninguna verificación.
+*/
+
+// Bits de permiso de cada dígito UGO de I_perm
+const (
+	permRead  byte = 4
+	permWrite byte = 2
+	permExec  byte = 1
+)
+
+// hasPermission indica si el usuario de la sesión tiene el permiso perm sobre el inodo.
+// root siempre tiene todos los permisos.
+func hasPermission(session *stores.Session, inode *structures.Inode, perm byte) bool {
+	if session.Username == "root" {
+		return true
+	}
+
+	uid, _ := strconv.Atoi(session.UID)
+
+	// I_perm guarda los tres dígitos octales como caracteres ASCII
+	var digit byte
+	switch {
+	case inode.I_uid == int32(uid):
+		digit = inode.I_perm[0]
+	case inSessionGroup(session, inode.I_gid):
+		digit = inode.I_perm[1]
+	default:
+		digit = inode.I_perm[2]
+	}
+	return (digit-'0')&perm != 0
+}
+
+// inSessionGroup indica si gid es el grupo principal o uno de los grupos adicionales del usuario de la sesión
+func inSessionGroup(session *stores.Session, gid int32) bool {
+	id := strconv.Itoa(int(gid))
+	return session.GID == id || slices.Contains(session.Groups, id)
+}
+
+// checkPermission devuelve un error que nombra itemPath si la sesión no tiene el permiso perm sobre el inodo
+func checkPermission(session *stores.Session, inode *structures.Inode, perm byte, itemPath string) error {
+	if hasPermission(session, inode, perm) {
+		return nil
+	}
+	name := "ejecución"
+	switch perm {
+	case permRead:
+		name = "lectura"
+	case permWrite:
+		name = "escritura"
+	}
+	return fmt.Errorf("permiso de %s denegado en %s", name, itemPath)
+}
+
+// isOwner indica si el usuario de la sesión es root o el propietario del inodo
+func isOwner(session *stores.Session, inode *structures.Inode) bool {
+	if session.Username == "root" {
+		return true
+	}
+	uid, _ := strconv.Atoi(session.UID)
+	return inode.I_uid == int32(uid)
+}
+
+// sessionOwner devuelve el UID y GID de la sesión para asignarlos a inodos nuevos
+func sessionOwner(session *stores.Session) (int32, int32, error) {
+	uid, err := strconv.Atoi(session.UID)
+	if err != nil {
+		return -1, -1, fmt.Errorf("error convirtiendo UID: %v", err)
+	}
+	gid, err := strconv.Atoi(session.GID)
+	if err != nil {
+		return -1, -1, fmt.Errorf("error convirtiendo GID: %v", err)
+	}
+	return int32(uid), int32(gid), nil
+}
+
+// resolvePath recorre los componentes de una ruta absoluta desde la raíz y devuelve el último inodo.
+// Cada carpeta atravesada debe poder leerse; el error indica el componente que falló.
+func resolvePath(session *stores.Session, sb *structures.SuperBlock, disk *structures.Disk, components []string) (int32, *structures.Inode, error) {
+	return walkPath(session, sb, disk, components, false)
+}
+
+// ensureDirectory funciona como resolvePath, pero crea las carpetas que falten si create es verdadero.
+// Crear una carpeta requiere permiso de escritura sobre la carpeta que la contiene.
+func ensureDirectory(session *stores.Session, sb *structures.SuperBlock, disk *structures.Disk, components []string, create bool) (int32, *structures.Inode, error) {
+	currentNum, current, err := walkPath(session, sb, disk, components, create)
+	if err != nil {
+		return -1, nil, err
+	}
+	if current.I_type[0] != '0' {
+		return -1, nil, fmt.Errorf("%s no es un directorio", joinPath(components))
+	}
+	return currentNum, current, nil
+}
+
+func walkPath(session *stores.Session, sb *structures.SuperBlock, disk *structures.Disk, components []string, create bool) (int32, *structures.Inode, error) {
+	currentNum := int32(0) // Raíz
+	current := &structures.Inode{}
+	err := current.Deserialize(disk, sb.InodeOffset(currentNum))
+	if err != nil {
+		return -1, nil, fmt.Errorf("error al leer inodo raíz: %v", err)
+	}
+
+	var visited []string
+	for _, name := range components {
+		if name == "" {
+			continue
+		}
+		currentPath := joinPath(visited)
+		if current.I_type[0] != '0' {
+			return -1, nil, fmt.Errorf("%s no es un directorio", currentPath)
+		}
+		if err := checkPermission(session, current, permRead, currentPath); err != nil {
+			return -1, nil, err
+		}
+		visited = append(visited, name)
+
+		childNum, err := sb.FindEntry(disk, current, name)
+		if err != nil {
+			return -1, nil, err
+		}
+		if childNum == -1 {
+			if !create {
+				return -1, nil, fmt.Errorf("%s no encontrado", joinPath(visited))
+			}
+			if err := checkPermission(session, current, permWrite, currentPath); err != nil {
+				return -1, nil, err
+			}
+			uid, gid, err := sessionOwner(session)
+			if err != nil {
+				return -1, nil, err
+			}
+			childNum, err = sb.MakeFolder(disk, currentNum, current, name, uid, gid, [3]byte{'6', '6', '4'})
+			if err != nil {
+				return -1, nil, err
+			}
+		}
+
+		currentNum = childNum
+		current = &structures.Inode{}
+		err = current.Deserialize(disk, sb.InodeOffset(currentNum))
+		if err != nil {
+			return -1, nil, fmt.Errorf("error al leer inodo %d: %v", currentNum, err)
+		}
+	}
+
+	return currentNum, current, nil
+}
+
+// joinPath arma la ruta absoluta a partir de sus componentes
+func joinPath(components []string) string {
+	return "/" + strings.Join(components, "/")
+}
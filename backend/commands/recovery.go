@@ -0,0 +1,185 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	accounts "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/accounts"
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// RECOVERY estructura que representa el comando recovery con sus parámetros
+type RECOVERY struct {
+	id string // ID de la partición
+}
+
+/*
+   recovery -id=671A
+*/
+
+// replayParsers son los comandos que pueden repetirse desde el journal
+var replayParsers = map[string]func(context.Context, []string) (string, error){
+	"mkdir":   ParseMkdir,
+	"mkfile":  ParseMkfile,
+	"remove":  ParseRemove,
+	"edit":    ParseEdit,
+	"rename":  ParseRename,
+	"copy":    ParseCopy,
+	"move":    ParseMove,
+	"chmod":   ParseChmod,
+	"chown":   ParseChown,
+	"mkgrp":   ParseMkgrp,
+	"rmgrp":   ParseRmgrp,
+	"mkusr":   ParseMkusr,
+	"rmusr":   ParseRmusr,
+	"chgrp":   ParseChgrp,
+	"usermod": ParseUsermod,
+}
+
+func ParseRecovery(tokens []string) (string, error) {
+	cmd := &RECOVERY{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-id":
+			if len(parts) != 2 || parts[1] == "" {
+				return "", fmt.Errorf("formato inválido para -id: %s", token)
+			}
+			cmd.id = parts[1]
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.id == "" {
+		return "", errors.New("faltan parámetros requeridos: -id")
+	}
+
+	output, err := commandRecovery(cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al recuperar la partición: %v", err)
+	}
+
+	return output, nil
+}
+
+// commandRecovery vuelve a crear el sistema de archivos vacío y repite las operaciones del journal.
+// Cada operación se ejecuta con el mismo comando que la registró, como el usuario y el grupo que la
+// ejecutaron, y vuelve a quedar en el journal.
+func commandRecovery(recovery *RECOVERY) (string, error) {
+	sb, mountedPartition, disk, err := stores.GetMountedPartitionSuperblock(recovery.id)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+	if !sb.IsExt3() {
+		return "", errors.New("recovery solo está disponible para particiones EXT3")
+	}
+
+	entries, err := sb.ReadJournal(disk)
+	if err != nil {
+		return "", err
+	}
+
+	// Dejar la partición como recién formateada, con el journal vacío
+	sb.S_free_inodes_count = sb.S_inodes_count - 2 // Raíz y users.txt
+	sb.S_free_blocks_count = sb.S_blocks_count - 2
+	if err := sb.ClearFileSystem(disk); err != nil {
+		return "", err
+	}
+	if err := sb.CreateJournal(disk); err != nil {
+		return "", err
+	}
+	if err := sb.CreateBitMaps(disk); err != nil {
+		return "", err
+	}
+	users, err := accounts.New()
+	if err != nil {
+		return "", err
+	}
+	if err := sb.CreateUsersFile(disk, users.String()); err != nil {
+		return "", err
+	}
+	if err := sb.Serialize(disk, int64(mountedPartition.Part_start)); err != nil {
+		return "", err
+	}
+
+	// Repetir las operaciones sobre esta partición con un token propio,
+	// para no tocar la sesión de quien ejecuta recovery
+	token, err := stores.NewSessionToken()
+	if err != nil {
+		return "", fmt.Errorf("error al crear la sesión de recuperación: %v", err)
+	}
+	defer stores.DeleteSessionToken(token)
+	ctx := stores.WithSessionToken(context.Background(), token)
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("RECOVERY: %d operaciones del journal repetidas en %s", len(entries), recovery.id))
+	for _, entry := range entries {
+		tokens := []string{}
+		if entry.Path() != "" {
+			tokens = append(tokens, "-path="+entry.Path())
+		}
+		tokens = append(tokens, utils.SplitCommand(entry.Content())...)
+
+		parse, ok := replayParsers[entry.Operation()]
+		if !ok {
+			output.WriteString(fmt.Sprintf("\n  %d %s: operación no soportada", entry.J_count, entry.Operation()))
+			continue
+		}
+		session, err := replaySession(recovery.id, &entry)
+		if err != nil {
+			output.WriteString(fmt.Sprintf("\n  %d %s %s: %v", entry.J_count, entry.Operation(), entry.Path(), err))
+			continue
+		}
+		if err := stores.SetSession(ctx, session); err != nil {
+			return "", err
+		}
+		if _, err := parse(ctx, tokens); err != nil {
+			output.WriteString(fmt.Sprintf("\n  %d %s %s: %v", entry.J_count, entry.Operation(), entry.Path(), err))
+			continue
+		}
+		if err := RecordJournal(ctx, entry.Operation(), tokens); err != nil {
+			return "", err
+		}
+	}
+
+	return output.String(), nil
+}
+
+// replaySession devuelve la sesión con la que se repite una entrada del journal: el usuario y el grupo
+// que la registraron, con los grupos adicionales que el usuario tiene en el users.txt recuperado hasta
+// ese punto.
+func replaySession(id string, entry *structures.Journal) (stores.Session, error) {
+	sb, _, disk, err := stores.GetMountedPartitionSuperblock(id)
+	if err != nil {
+		return stores.Session{}, err
+	}
+	users, err := accounts.Load(sb, disk)
+	if err != nil {
+		return stores.Session{}, err
+	}
+	user := users.UserByID(entry.J_uid)
+	if user == nil {
+		return stores.Session{}, fmt.Errorf("el usuario %d que registró la operación no existe", entry.J_uid)
+	}
+	_, groups, err := sessionGroups(users, user)
+	if err != nil {
+		return stores.Session{}, err
+	}
+
+	return stores.Session{
+		ID:       id,
+		Username: user.Name,
+		UID:      strconv.Itoa(int(entry.J_uid)),
+		GID:      strconv.Itoa(int(entry.J_gid)),
+		Groups:   groups,
+	}, nil
+}
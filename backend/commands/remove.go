@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// REMOVE estructura que representa el comando remove con sus parámetros
+type REMOVE struct {
+	path string // Ruta del archivo o carpeta a eliminar
+}
+
+/*
+   remove -path=/home/user/docs/a.txt
+   remove -path=/home/user/docs
+*/
+
+func ParseRemove(ctx context.Context, tokens []string) (string, error) {
+	cmd := &REMOVE{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-path":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -path: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if value == "" {
+				return "", errors.New("el path no puede estar vacío")
+			}
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("la ruta debe ser absoluta (comenzar con /)")
+			}
+			cmd.path = value
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.path == "" {
+		return "", errors.New("faltan parámetros requeridos: -path")
+	}
+
+	err := commandRemove(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al eliminar: %v", err)
+	}
+
+	return fmt.Sprintf("REMOVE: %s eliminado correctamente", cmd.path), nil
+}
+
+func commandRemove(ctx context.Context, remove *REMOVE) error {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return errors.New("debe iniciar sesión primero")
+	}
+
+	sb, mountedPartition, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	parentDirs, name := utils.GetParentDirectories(remove.path)
+	if name == "" {
+		return errors.New("no se puede eliminar la raíz")
+	}
+	if len(parentDirs) == 0 && name == "users.txt" {
+		return errors.New("no se puede eliminar /users.txt")
+	}
+
+	// Buscar el directorio padre y la entrada a eliminar
+	_, parent, err := ensureDirectory(session, sb, disk, parentDirs, false)
+	if err != nil {
+		return fmt.Errorf("directorio padre inválido: %w", err)
+	}
+	if err := checkPermission(session, parent, permWrite, joinPath(parentDirs)); err != nil {
+		return err
+	}
+	targetNum, err := sb.FindEntry(disk, parent, name)
+	if err != nil {
+		return err
+	}
+	if targetNum == -1 {
+		return fmt.Errorf("%s no existe", remove.path)
+	}
+
+	// Verificar permisos de escritura en todo el subárbol antes de modificar nada
+	err = checkSubtreeWrite(session, sb, disk, targetNum, remove.path)
+	if err != nil {
+		return err
+	}
+
+	// Desvincular del padre y liberar inodos y bloques
+	if _, err := sb.RemoveEntry(disk, parent, name); err != nil {
+		return err
+	}
+	if err := sb.DeleteInode(disk, targetNum); err != nil {
+		return err
+	}
+
+	// Serializar el superbloque con los nuevos conteos libres
+	err = sb.Serialize(disk, int64(mountedPartition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error al serializar el superbloque: %w", err)
+	}
+
+	return nil
+}
+
+// checkSubtreeWrite verifica que la sesión actual tenga permiso de escritura sobre el inodo y todos sus descendientes
+func checkSubtreeWrite(session *stores.Session, sb *structures.SuperBlock, disk *structures.Disk, inodeNum int32, itemPath string) error {
+	return sb.WalkTree(disk, inodeNum, itemPath, func(_ int32, inode *structures.Inode, itemPath string) error {
+		return checkPermission(session, inode, permWrite, itemPath)
+	})
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// RENAME estructura que representa el comando rename con sus parámetros
+type RENAME struct {
+	path string // Ruta del archivo o carpeta
+	name string // Nuevo nombre
+}
+
+/*
+   rename -path=/home/user/docs/a.txt -name=b.txt
+*/
+
+func ParseRename(ctx context.Context, tokens []string) (string, error) {
+	cmd := &RENAME{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-path":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -path: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if !strings.HasPrefix(value, "/") {
+				return "", errors.New("la ruta debe ser absoluta (comenzar con /)")
+			}
+			cmd.path = value
+		case "-name":
+			if len(parts) != 2 {
+				return "", fmt.Errorf("formato inválido para -name: %s", token)
+			}
+			value := strings.Trim(parts[1], "\"")
+			if value == "" || strings.Contains(value, "/") {
+				return "", fmt.Errorf("nombre inválido: %s", value)
+			}
+			if len(value) > 12 {
+				return "", fmt.Errorf("el nombre %s excede 12 caracteres", value)
+			}
+			cmd.name = value
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.path == "" || cmd.name == "" {
+		return "", errors.New("faltan parámetros requeridos: -path, -name")
+	}
+
+	err := commandRename(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al renombrar: %v", err)
+	}
+
+	return fmt.Sprintf("RENAME: %s renombrado a %s correctamente", cmd.path, cmd.name), nil
+}
+
+func commandRename(ctx context.Context, rename *RENAME) error {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return errors.New("debe iniciar sesión primero")
+	}
+
+	sb, _, disk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	parentDirs, name := utils.GetParentDirectories(rename.path)
+	if name == "" {
+		return errors.New("no se puede renombrar la raíz")
+	}
+	if len(parentDirs) == 0 && name == "users.txt" {
+		return errors.New("no se puede renombrar /users.txt")
+	}
+
+	_, parent, err := ensureDirectory(session, sb, disk, parentDirs, false)
+	if err != nil {
+		return fmt.Errorf("directorio padre inválido: %w", err)
+	}
+	targetNum, target, err := resolvePath(session, sb, disk, append(parentDirs, name))
+	if err != nil {
+		return fmt.Errorf("%s no existe: %w", rename.path, err)
+	}
+	if targetNum == 0 {
+		return errors.New("no se puede renombrar la raíz")
+	}
+
+	// Se modifica el archivo y la entrada del directorio que lo contiene
+	if err := checkPermission(session, target, permWrite, rename.path); err != nil {
+		return err
+	}
+	if err := checkPermission(session, parent, permWrite, joinPath(parentDirs)); err != nil {
+		return err
+	}
+
+	return sb.RenameEntry(disk, parent, name, rename.name)
+}
@@ -44,9 +44,9 @@ func ParseRep(tokens []string) (string, error) {
 			}
 			cmd.path = value
 		case "-name":
-			validNames := []string{"mbr", "ebr", "disk", "inode", "block", "bm_inode", "bm_block", "tree", "sb", "file", "ls"}
+			validNames := []string{"mbr", "ebr", "disk", "inode", "block", "bm_inode", "bm_block", "tree", "sb", "file", "ls", "journaling"}
 			if !contains(validNames, value) {
-				return "", errors.New("nombre inválido, debe ser: mbr, ebr, disk, inode, block, bm_inode, bm_block, tree, sb, file, ls")
+				return "", errors.New("nombre inválido, debe ser: mbr, ebr, disk, inode, block, bm_inode, bm_block, tree, sb, file, ls, journaling")
 			}
 			cmd.name = value
 		case "-path_file_ls":
@@ -91,12 +91,12 @@ func contains(list []string, value string) bool {
 }
 
 func commandRep(rep *REP) error {
-	mountedMbr, mountedSb, mountedDiskPath, err := stores.GetMountedPartitionRep(rep.id)
+	mountedMbr, mountedSb, mountedDisk, err := stores.GetMountedPartitionRep(rep.id)
 	if err != nil {
 		return err
 	}
 
-	requiresSuperblock := []string{"inode", "block", "bm_inode", "bm_block", "tree", "sb", "file", "ls"}
+	requiresSuperblock := []string{"inode", "block", "bm_inode", "bm_block", "tree", "sb", "file", "ls", "journaling"}
 	if contains(requiresSuperblock, rep.name) && mountedSb == nil {
 		return fmt.Errorf("error interno: superbloque no cargado para la partición %s", rep.id)
 	}
@@ -106,31 +106,31 @@ func commandRep(rep *REP) error {
 	case "mbr":
 		dotContent, err = reports.ReportMBR(mountedMbr)
 	case "ebr":
-		dotContent, err = reports.ReportEBR(mountedMbr, mountedDiskPath)
+		dotContent, err = reports.ReportEBR(mountedMbr, mountedDisk.Path)
 	case "disk":
-		dotContent, err = reports.ReportDisk(mountedMbr, mountedDiskPath)
+		dotContent, err = reports.ReportDisk(mountedMbr, mountedDisk.Path)
 	case "inode":
-		dotContent, err = reports.ReportInode(mountedSb, mountedDiskPath)
+		dotContent, err = reports.ReportInode(mountedSb, mountedDisk)
 	case "block":
-		dotContent, err = reports.ReportBlock(mountedSb, mountedDiskPath)
+		dotContent, err = reports.ReportBlock(mountedSb, mountedDisk)
 	case "bm_inode":
-		err = reports.ReportBMInode(mountedSb, mountedDiskPath, rep.path)
+		err = reports.ReportBMInode(mountedSb, mountedDisk, rep.path)
 		if err != nil {
 			return fmt.Errorf("error generando reporte bm_inode: %v", err)
 		}
 		return nil
 	case "bm_block":
-		err = reports.ReportBMBlock(mountedSb, mountedDiskPath, rep.path)
+		err = reports.ReportBMBlock(mountedSb, mountedDisk, rep.path)
 		if err != nil {
 			return fmt.Errorf("error generando reporte bm_block: %v", err)
 		}
 		return nil
 	case "tree":
-		dotContent, err = reports.ReportTree(mountedSb, mountedDiskPath)
+		dotContent, err = reports.ReportTree(mountedSb, mountedDisk)
 	case "sb":
 		dotContent, err = reports.ReportSB(mountedSb)
 	case "file":
-		dotContent, err = reports.ReportFile(mountedSb, mountedDiskPath, rep.path_file_ls)
+		dotContent, err = reports.ReportFile(mountedSb, mountedDisk, rep.path_file_ls)
 		if err != nil {
 			return fmt.Errorf("error generando reporte file: %v", err)
 		}
@@ -141,7 +141,9 @@ func commandRep(rep *REP) error {
 		}
 		return nil // No necesitamos generar imagen
 	case "ls":
-		dotContent, err = reports.ReportLS(mountedSb, mountedDiskPath, rep.path_file_ls)
+		dotContent, err = reports.ReportLS(mountedSb, mountedDisk, rep.path_file_ls)
+	case "journaling":
+		dotContent, err = reports.ReportJournaling(mountedSb, mountedDisk)
 	default:
 		return fmt.Errorf("reporte no implementado: %s", rep.name)
 	}
@@ -52,7 +52,7 @@ func commandRmdisk(rmdisk *RMDISK) error {
 	}
 
 	// Verificar si alguna partición del disco está montada
-	for id, mountedPath := range stores.MountedPartitions {
+	for id, mountedPath := range stores.MountedPartitions() {
 		if mountedPath == rmdisk.path {
 			return fmt.Errorf("el disco en %s tiene una partición montada (ID: %s), desmonte primero", rmdisk.path, id)
 		}
@@ -1,16 +1,14 @@
 package commands
 
 import (
-	"bytes"
-	"encoding/binary"
+	"context"
 	"errors"
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
+	accounts "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/accounts"
 	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
-	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
 )
 
 // RMGRP estructura que representa el comando rmgrp con sus parámetros
@@ -19,7 +17,7 @@ type RMGRP struct {
 }
 
 // ParseRmgrp parsea los tokens del comando rmgrp y ejecuta la acción
-func ParseRmgrp(tokens []string) (string, error) {
+func ParseRmgrp(ctx context.Context, tokens []string) (string, error) {
 	cmd := &RMGRP{}
 	args := strings.Join(tokens, " ")
 	re := regexp.MustCompile(`-name=[^\s]+`)
@@ -52,7 +50,7 @@ func ParseRmgrp(tokens []string) (string, error) {
 		return "", errors.New("faltan parámetros requeridos: -name")
 	}
 
-	err := commandRmgrp(cmd)
+	err := commandRmgrp(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
@@ -60,132 +58,35 @@ func ParseRmgrp(tokens []string) (string, error) {
 	return fmt.Sprintf("RMGRP: Grupo %s eliminado exitosamente", cmd.name), nil
 }
 
-func commandRmgrp(rmgrp *RMGRP) error {
-	if stores.CurrentSession.ID == "" {
+func commandRmgrp(ctx context.Context, rmgrp *RMGRP) error {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
 		return errors.New("no hay sesión activa, inicie sesión primero")
 	}
-	if stores.CurrentSession.Username != "root" {
+	if session.Username != "root" {
 		return errors.New("solo el usuario root puede eliminar grupos")
 	}
 
-	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(stores.CurrentSession.ID)
+	partitionSuperblock, _, partitionDisk, err := stores.GetMountedPartitionSuperblock(session.ID)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %v", err)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0644)
+	users, err := accounts.Load(partitionSuperblock, partitionDisk)
 	if err != nil {
-		return fmt.Errorf("error al abrir disco: %v", err)
+		return err
 	}
-	defer file.Close()
 
-	// Leer el inodo de users.txt (inodo 1)
-	usersInode := &structures.Inode{}
-	err = usersInode.Deserialize(partitionPath, int64(partitionSuperblock.S_inode_start+partitionSuperblock.S_inode_size))
-	if err != nil {
-		return fmt.Errorf("error al leer el inodo de users.txt: %v", err)
-	}
-
-	if usersInode.I_type[0] != '1' {
-		return errors.New("users.txt no es un archivo válido")
-	}
-
-	// Leer el contenido actual de users.txt
-	var content strings.Builder
-	for _, blockNum := range usersInode.I_block[:12] {
-		if blockNum == -1 {
-			break
-		}
-		fileBlock := &structures.FileBlock{}
-		err = fileBlock.Deserialize(partitionPath, int64(partitionSuperblock.S_block_start+blockNum*partitionSuperblock.S_block_size))
-		if err != nil {
-			return fmt.Errorf("error al leer el bloque de users.txt: %v", err)
-		}
-		content.Write(bytes.Trim(fileBlock.B_content[:], "\x00"))
-	}
-	usersContent := strings.TrimSpace(content.String())
-
-	// Procesar contenido y eliminar grupo
-	lines := strings.Split(usersContent, "\n")
-	found := false
-	for i, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, ",")
-		if len(parts) < 3 {
-			continue
-		}
-		if parts[1] == "G" && parts[2] == rmgrp.name && parts[0] != "0" {
-			lines[i] = fmt.Sprintf("0,G,%s", rmgrp.name)
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		return errors.New("el grupo no existe o ya está eliminado")
-	}
-
-	updatedContent := strings.Join(lines, "\n")
-
-	// Escribir el contenido actualizado
-	blockSize := int(partitionSuperblock.S_block_size) // 64 bytes
-	contentBytes := []byte(updatedContent)
-	numBlocksNeeded := (len(contentBytes) + blockSize - 1) / blockSize
-
-	if numBlocksNeeded > 12 {
-		return errors.New("el archivo users.txt excede el límite de bloques directos (12)")
-	}
-
-	// Reutilizar o asignar bloques
-	for i := 0; i < numBlocksNeeded; i++ {
-		start := i * blockSize
-		end := start + blockSize
-		if end > len(contentBytes) {
-			end = len(contentBytes)
-		}
-		blockContent := contentBytes[start:end]
-
-		var blockNum int32
-		if i < len(usersInode.I_block) && usersInode.I_block[i] != -1 {
-			blockNum = usersInode.I_block[i] // Reutilizar bloque existente
-		} else {
-			if partitionSuperblock.S_free_blocks_count <= 0 {
-				return errors.New("no hay bloques libres disponibles")
-			}
-			blockNum = partitionSuperblock.S_first_blo
-			partitionSuperblock.S_first_blo++
-			partitionSuperblock.S_free_blocks_count--
-			partitionSuperblock.S_blocks_count++
-			usersInode.I_block[i] = blockNum
-
-			err = setBitmapBit(partitionPath, int64(partitionSuperblock.S_bm_block_start), int(blockNum), 1)
-			if err != nil {
-				return fmt.Errorf("error al actualizar bitmap de bloques: %v", err)
-			}
-		}
-
-		fileBlock := &structures.FileBlock{}
-		copy(fileBlock.B_content[:], blockContent)
-		err = fileBlock.Serialize(partitionPath, int64(partitionSuperblock.S_block_start+blockNum*int32(partitionSuperblock.S_block_size)))
-		if err != nil {
-			return fmt.Errorf("error al escribir bloque %d: %v", blockNum, err)
-		}
+	if err := users.RemoveGroup(rmgrp.name); err != nil {
+		return err
 	}
 
-	// Actualizar inodo
-	usersInode.I_size = int32(len(contentBytes))
-	err = usersInode.Serialize(partitionPath, int64(partitionSuperblock.S_inode_start+partitionSuperblock.S_inode_size))
-	if err != nil {
-		return fmt.Errorf("error al actualizar inodo: %v", err)
+	if err := accounts.Save(partitionSuperblock, partitionDisk, users); err != nil {
+		return err
 	}
-
-	// Actualizar superbloque
-	err = partitionSuperblock.Serialize(partitionPath, int64(partitionSuperblock.S_inode_start-int32(binary.Size(structures.SuperBlock{}))))
-	if err != nil {
-		return fmt.Errorf("error al actualizar superbloque: %v", err)
+	// El grupo también se quitó de los grupos adicionales de sus miembros
+	for _, user := range users.Users() {
+		refreshUserSessions(session.ID, users, user.Name)
 	}
-
 	return nil
 }
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+)
+
+// SYNC estructura que representa el comando sync con sus parámetros
+type SYNC struct {
+	id string // ID de la partición (opcional, sin él se sincronizan todos los discos)
+}
+
+/*
+   sync
+   sync -id=671A
+*/
+
+func ParseSync(tokens []string) (string, error) {
+	cmd := &SYNC{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		key := strings.ToLower(parts[0])
+
+		switch key {
+		case "-id":
+			if len(parts) != 2 || parts[1] == "" {
+				return "", fmt.Errorf("formato inválido para -id: %s", token)
+			}
+			cmd.id = parts[1]
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	written, err := commandSync(cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al sincronizar: %v", err)
+	}
+
+	if cmd.id != "" {
+		return fmt.Sprintf("SYNC: %d páginas escritas en el disco de %s", written, cmd.id), nil
+	}
+	return fmt.Sprintf("SYNC: %d páginas escritas en disco", written), nil
+}
+
+func commandSync(sync *SYNC) (int, error) {
+	if sync.id == "" {
+		return structures.SyncAll()
+	}
+
+	disk, err := stores.GetMountedDisk(sync.id)
+	if err != nil {
+		return 0, err
+	}
+	return disk.Sync()
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+)
+
+// UNMOUNT estructura que representa el comando unmount con sus parámetros
+type UNMOUNT struct {
+	id string // ID de la partición montada
+}
+
+/*
+	unmount -id=671A
+*/
+
+// ParseUnmount parsea los tokens del comando unmount
+func ParseUnmount(tokens []string) (string, error) {
+	cmd := &UNMOUNT{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("formato inválido: %s", token)
+		}
+		key := strings.ToLower(parts[0])
+		value := parts[1]
+
+		switch key {
+		case "-id":
+			if value == "" {
+				return "", errors.New("el id no puede estar vacío")
+			}
+			cmd.id = value
+		default:
+			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.id == "" {
+		return "", errors.New("faltan parámetros requeridos: -id")
+	}
+
+	err := commandUnmount(cmd)
+	if err != nil {
+		return "", fmt.Errorf("error al desmontar la partición: %v", err)
+	}
+
+	return fmt.Sprintf("UNMOUNT: Partición %s desmontada correctamente", cmd.id), nil
+}
+
+func commandUnmount(unmount *UNMOUNT) error {
+	path, exists := stores.MountedPath(unmount.id)
+	if !exists {
+		return fmt.Errorf("la partición %s no está montada", unmount.id)
+	}
+
+	disk, err := stores.GetMountedDisk(unmount.id)
+	if err != nil {
+		return err
+	}
+
+	// Registrar la hora de desmontaje si la partición está formateada
+	var sb structures.SuperBlock
+	if err := sb.Deserialize(disk, disk.Start()); err == nil && sb.S_magic == 0xEF53 {
+		sb.S_umtime = float32(time.Now().Unix())
+		if err := sb.Serialize(disk, disk.Start()); err != nil {
+			return fmt.Errorf("error al actualizar el superbloque: %v", err)
+		}
+	}
+	if err := stores.CloseMountedDisk(unmount.id); err != nil {
+		return fmt.Errorf("error al cerrar el disco: %v", err)
+	}
+
+	var mbr structures.MBR
+	if err := mbr.Deserialize(path); err != nil {
+		return fmt.Errorf("error al deserializar MBR: %v", err)
+	}
+
+	// Limpiar el estado de montaje en el MBR o en el EBR de la partición lógica
+	if partition, _ := mbr.GetPartitionByID(unmount.id); partition != nil {
+		partition.UnmountPartition()
+		if err := mbr.Serialize(path); err != nil {
+			return fmt.Errorf("error al serializar MBR: %v", err)
+		}
+	} else if err := unmountLogicalPartition(&mbr, path, unmount.id); err != nil {
+		return err
+	}
+
+	// Cerrar las sesiones iniciadas en esta partición
+	stores.ClearPartitionSessions(unmount.id)
+
+	stores.RemoveMount(unmount.id)
+	if err := stores.SaveState(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// unmountLogicalPartition limpia el estado de montaje del EBR con el ID indicado
+func unmountLogicalPartition(mbr *structures.MBR, path string, id string) error {
+	var extPartition *structures.Partition
+	for _, p := range mbr.Mbr_partitions {
+		if p.Part_type[0] == 'E' && p.Part_status[0] != 'N' {
+			extPartition = &p
+			break
+		}
+	}
+	if extPartition == nil {
+		return fmt.Errorf("partición %s no encontrada en el disco", id)
+	}
+
+	image, err := structures.OpenImage(path)
+	if err != nil {
+		return fmt.Errorf("error al abrir disco: %v", err)
+	}
+	defer image.Close()
+
+	var currentEBR structures.EBR
+	currentOffset := int64(extPartition.Part_start)
+	for {
+		if err := currentEBR.Deserialize(image, currentOffset); err != nil {
+			return fmt.Errorf("error al leer EBR: %v", err)
+		}
+		if strings.Trim(string(currentEBR.Part_id[:]), "\x00") == id {
+			currentEBR.Part_status = [1]byte{'0'}
+			currentEBR.Part_id = [4]byte{}
+			if err := currentEBR.Serialize(image, currentOffset); err != nil {
+				return fmt.Errorf("error al serializar EBR: %v", err)
+			}
+			return nil
+		}
+		if currentEBR.Part_next == -1 {
+			return fmt.Errorf("partición lógica %s no encontrada", id)
+		}
+		currentOffset = int64(currentEBR.Part_next)
+	}
+}
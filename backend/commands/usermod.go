@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	accounts "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/accounts"
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+)
+
+// USERMOD estructura que representa el comando usermod con sus parámetros
+type USERMOD struct {
+	user   string   // Usuario a modificar
+	add    []string // Grupos adicionales a agregar
+	remove []string // Grupos adicionales a quitar
+}
+
+/*
+   usermod -user=juan -grp+=ventas
+   usermod -user=juan -grp+=ventas -grp+=compras -grp-=soporte
+*/
+
+func ParseUsermod(ctx context.Context, tokens []string) (string, error) {
+	cmd := &USERMOD{}
+
+	for _, token := range tokens {
+		parts := strings.SplitN(token, "=", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("formato de parámetro inválido: %s", token)
+		}
+		key := strings.ToLower(parts[0])
+		value := strings.Trim(parts[1], "\"")
+
+		switch key {
+		case "-user":
+			if value == "" || len(value) > 10 {
+				return "", errors.New("el usuario debe tener entre 1 y 10 caracteres")
+			}
+			cmd.user = value
+		case "-grp+", "-grp-":
+			if value == "" || len(value) > 10 {
+				return "", errors.New("el grupo debe tener entre 1 y 10 caracteres")
+			}
+			if key == "-grp+" {
+				cmd.add = append(cmd.add, value)
+			} else {
+				cmd.remove = append(cmd.remove, value)
+			}
+		default:
+			return "", fmt.Errorf("parámetro inválido: %s", key)
+		}
+	}
+
+	if cmd.user == "" {
+		return "", errors.New("faltan parámetros requeridos: -user")
+	}
+	if len(cmd.add) == 0 && len(cmd.remove) == 0 {
+		return "", errors.New("faltan parámetros requeridos: -grp+ o -grp-")
+	}
+
+	groups, err := commandUsermod(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	if len(groups) == 0 {
+		return fmt.Sprintf("USERMOD: El usuario %s no tiene grupos adicionales", cmd.user), nil
+	}
+	return fmt.Sprintf("USERMOD: Grupos adicionales de %s: %s", cmd.user, strings.Join(groups, ", ")), nil
+}
+
+// commandUsermod actualiza los grupos adicionales del usuario y los devuelve
+func commandUsermod(ctx context.Context, usermod *USERMOD) ([]string, error) {
+	session := stores.SessionFromContext(ctx)
+	if session.ID == "" {
+		return nil, errors.New("no hay sesión activa, inicie sesión primero")
+	}
+	if session.Username != "root" {
+		return nil, errors.New("solo el usuario root puede modificar usuarios")
+	}
+
+	partitionSuperblock, _, partitionDisk, err := stores.GetMountedPartitionSuperblock(session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la partición montada: %v", err)
+	}
+
+	users, err := accounts.Load(partitionSuperblock, partitionDisk)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range usermod.add {
+		if err := users.AddToGroup(usermod.user, group); err != nil {
+			return nil, err
+		}
+	}
+	for _, group := range usermod.remove {
+		if err := users.RemoveFromGroup(usermod.user, group); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := accounts.Save(partitionSuperblock, partitionDisk, users); err != nil {
+		return nil, err
+	}
+	refreshUserSessions(session.ID, users, usermod.user)
+	return users.User(usermod.user).Groups, nil
+}
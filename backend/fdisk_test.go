@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fdisk -add no debe achicar una partición lógica formateada por debajo de su sistema de archivos,
+// que empieza después del EBR
+func TestFdiskShrinkFormattedLogical(t *testing.T) {
+	app, dir := newTestServer(t)
+	admin := &testClient{t: t, app: app}
+	disk := filepath.Join(dir, "d.mia")
+	output := admin.mustRun(fmt.Sprintf("mkdisk -size=6 -unit=M -path=%s\n"+
+		"fdisk -size=4 -unit=M -type=E -name=EXT -path=%s\n"+
+		"fdisk -size=1000 -unit=K -type=L -name=L1 -path=%s\n"+
+		"mount -name=L1 -path=%s", disk, disk, disk, disk))
+	match := mountedID.FindStringSubmatch(output)
+	if match == nil {
+		t.Fatalf("no se montó la partición:\n%s", output)
+	}
+	id := match[1]
+	admin.mustRun(fmt.Sprintf("mkfs -id=%s -fs=2fs\nunmount -id=%s", id, id))
+
+	output = admin.run(fmt.Sprintf("fdisk -add=-900 -unit=K -name=L1 -path=%s", disk))
+	if !strings.Contains(output, "no puede medir menos") {
+		t.Fatalf("fdisk -add achicó la lógica formateada:\n%s", output)
+	}
+
+	// Quitar espacio que el sistema de archivos no usa sí se permite
+	admin.mustRun(fmt.Sprintf("fdisk -add=100 -unit=K -name=L1 -path=%s\nfdisk -add=-100 -unit=K -name=L1 -path=%s", disk, disk))
+	output = admin.mustRun(fmt.Sprintf("mount -name=L1 -path=%s", disk))
+	if match = mountedID.FindStringSubmatch(output); match == nil {
+		t.Fatalf("no se volvió a montar la partición:\n%s", output)
+	}
+	checkFsck(t, admin, match[1])
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	analyzer "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/analyzer"
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -18,10 +23,74 @@ type CommandResponse struct {
 	Output string `json:"output"`
 }
 
+// Cada cliente identifica su sesión con este encabezado o, si no lo envía, con esta cookie
+const (
+	sessionHeader = "X-Session-Token"
+	sessionCookie = "mia_session"
+)
+
+// sessionToken devuelve el token de sesión de la petición; si no trae uno vigente, emite uno nuevo.
+// El token se devuelve en el encabezado y en la cookie de la respuesta.
+func sessionToken(c *fiber.Ctx) (string, error) {
+	token := c.Get(sessionHeader)
+	if token == "" {
+		token = c.Cookies(sessionCookie)
+	}
+	if !stores.TouchSession(token) {
+		var err error
+		if token, err = stores.NewSessionToken(); err != nil {
+			return "", err
+		}
+	}
+
+	c.Set(sessionHeader, token)
+	c.Cookie(&fiber.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+	return token, nil
+}
+
 func main() {
+	// Restaurar las particiones montadas antes de la última ejecución
+	if err := stores.LoadState(); err != nil {
+		fmt.Printf("Error al restaurar el estado de montaje: %v\n", err)
+	}
+
+	app := newApp()
+
+	// Con Ctrl+C o SIGTERM se terminan las peticiones en curso antes de salir
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		app.Shutdown()
+	}()
+
+	if err := app.Listen(":3001"); err != nil {
+		fmt.Printf("Error en el servidor: %v\n", err)
+	}
+
+	// Las páginas de la caché que no se sincronizaron se perderían al salir
+	written, err := structures.SyncAll()
+	if err != nil {
+		fmt.Printf("Error al sincronizar los discos: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Discos sincronizados: %d páginas escritas\n", written)
+}
+
+// newApp crea el servidor con sus rutas. Fiber atiende cada petición en su propia goroutine;
+// los comandos se coordinan con los candados de stores/locks.go.
+func newApp() *fiber.App {
 	app := fiber.New()
 
-	app.Use(cors.New(cors.Config{}))
+	app.Use(cors.New(cors.Config{
+		AllowHeaders:  "Origin, Content-Type, Accept, " + sessionHeader,
+		ExposeHeaders: sessionHeader,
+	}))
 
 	app.Post("/execute", func(c *fiber.Ctx) error {
 		var req CommandRequest
@@ -31,6 +100,14 @@ func main() {
 			})
 		}
 
+		token, err := sessionToken(c)
+		if err != nil {
+			return c.Status(500).JSON(CommandResponse{
+				Output: "Error: No se pudo crear la sesión",
+			})
+		}
+		ctx := stores.WithSessionToken(c.UserContext(), token)
+
 		commands := strings.Split(req.Command, "\n")
 		output := ""
 
@@ -39,7 +116,7 @@ func main() {
 				continue
 			}
 
-			result, err := analyzer.Analyzer(cmd)
+			result, err := analyzer.Analyzer(ctx, cmd)
 			if err != nil {
 				output += fmt.Sprintf("Error: %s\n", err.Error())
 			} else {
@@ -56,5 +133,5 @@ func main() {
 		})
 	})
 
-	app.Listen(":3001")
+	return app
 }
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	stores "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/stores"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+Pruebas de concurrencia: varios clientes, cada uno con su token de sesión, envían
+comandos a /execute en paralelo. Deben ejecutarse con el detector de carreras:
+
+	go test -race .
+*/
+
+// testClient es un cliente del servidor con su propio token de sesión
+type testClient struct {
+	t     *testing.T
+	app   *fiber.App
+	token string
+}
+
+// run envía los comandos a /execute y devuelve la salida
+func (c *testClient) run(command string) string {
+	body, _ := json.Marshal(CommandRequest{Command: command})
+	req := httptest.NewRequest("POST", "/execute", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set(sessionHeader, c.token)
+	}
+
+	resp, err := c.app.Test(req, -1)
+	if err != nil {
+		c.t.Errorf("%s: %v", command, err)
+		return ""
+	}
+	defer resp.Body.Close()
+	c.token = resp.Header.Get(sessionHeader)
+
+	data, _ := io.ReadAll(resp.Body)
+	var out CommandResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		c.t.Errorf("%s: respuesta inválida %q", command, data)
+	}
+	return out.Output
+}
+
+// mustRun envía los comandos y marca la prueba como fallida si alguno devolvió error.
+// Se puede llamar desde varias goroutines.
+func (c *testClient) mustRun(command string) string {
+	output := c.run(command)
+	if strings.Contains(output, "Error:") {
+		c.t.Errorf("%s:\n%s", command, output)
+	}
+	return output
+}
+
+// newTestServer crea un servidor con un estado de montaje vacío.
+// Al terminar la prueba se desmontan las particiones que hayan quedado montadas.
+func newTestServer(t *testing.T) (*fiber.App, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(stores.StateFileEnv, filepath.Join(dir, "state.json"))
+	utils.SetLetterState(utils.LetterState{})
+
+	app := newApp()
+	t.Cleanup(func() {
+		admin := &testClient{t: t, app: app}
+		for id := range stores.MountedPartitions() {
+			admin.run("unmount -id=" + id)
+		}
+	})
+	return app, dir
+}
+
+var mountedID = regexp.MustCompile(`montada correctamente con ID: (\w+)`)
+
+// setupPartition crea un disco con una partición formateada y devuelve su ID
+func setupPartition(c *testClient, diskPath string, fs string) string {
+	output := c.mustRun(fmt.Sprintf("mkdisk -size=6 -unit=M -path=%s\nfdisk -size=5 -unit=M -name=P1 -path=%s\nmount -name=P1 -path=%s", diskPath, diskPath, diskPath))
+	match := mountedID.FindStringSubmatch(output)
+	if match == nil {
+		c.t.Fatalf("no se montó la partición:\n%s", output)
+	}
+	c.mustRun(fmt.Sprintf("mkfs -id=%s -fs=%s", match[1], fs))
+	return match[1]
+}
+
+// checkFsck verifica que fsck no encuentre problemas y devuelve su resumen
+func checkFsck(t *testing.T, c *testClient, id string) string {
+	t.Helper()
+	output := c.mustRun("fsck -id=" + id)
+	if !strings.Contains(output, "Sin problemas encontrados") {
+		t.Errorf("fsck encontró problemas en %s:\n%s", id, output)
+	}
+	return output
+}
+
+// Varios clientes crean archivos en la misma carpeta a la vez: ninguno debe recibir un
+// inodo o bloque que otro ya reservó.
+func TestParallelMkfile(t *testing.T) {
+	app, dir := newTestServer(t)
+	admin := &testClient{t: t, app: app}
+	id := setupPartition(admin, filepath.Join(dir, "d1.mia"), "2fs")
+	admin.mustRun("login -user=root -pass=123 -id=" + id + "\nmkdir -path=/shared")
+
+	const clients, files = 8, 10
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := &testClient{t: t, app: app}
+			c.mustRun("login -user=root -pass=123 -id=" + id)
+			for j := range files {
+				c.mustRun(fmt.Sprintf("mkfile -path=/shared/c%d_%d.txt -size=%d", i, j, 10+i*70+j))
+			}
+		}()
+	}
+	wg.Wait()
+
+	summary := checkFsck(t, admin, id)
+	if want := fmt.Sprintf("2 carpetas y %d archivos", clients*files+1); !strings.Contains(summary, want) {
+		t.Errorf("se esperaba %q en:\n%s", want, summary)
+	}
+	for i := range clients {
+		output := admin.mustRun(fmt.Sprintf("cat -file1=/shared/c%d_%d.txt", i, files-1))
+		lines := strings.Split(output, "\n")
+		if size := 10 + i*70 + files - 1; len(lines) < 2 || len(lines[1]) != size {
+			t.Errorf("c%d_%d.txt no tiene %d bytes:\n%s", i, files-1, size, output)
+		}
+	}
+}
+
+// Lectores (cat, find, rep) y escritores (mkfile, edit, mkusr) sobre la misma partición EXT3
+func TestParallelReadersAndWriters(t *testing.T) {
+	app, dir := newTestServer(t)
+	admin := &testClient{t: t, app: app}
+	id := setupPartition(admin, filepath.Join(dir, "d2.mia"), "3fs")
+	admin.mustRun("login -user=root -pass=123 -id=" + id + "\nmkgrp -name=devs\nmkfile -path=/log.txt -size=5")
+
+	extra := filepath.Join(dir, "extra.txt")
+	if err := os.WriteFile(extra, []byte("+linea\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c := &testClient{t: t, app: app}
+			c.mustRun("login -user=root -pass=123 -id=" + id)
+			for j := range 5 {
+				c.mustRun(fmt.Sprintf("mkfile -r -path=/w%d/f%d.txt -size=30", i, j))
+				c.mustRun("edit -append -path=/log.txt -contenido=" + extra)
+			}
+			c.mustRun(fmt.Sprintf("mkusr -user=u%d -pass=x -grp=devs", i))
+		}()
+		go func() {
+			defer wg.Done()
+			c := &testClient{t: t, app: app}
+			c.mustRun("login -user=root -pass=123 -id=" + id)
+			for range 5 {
+				c.mustRun("cat -file1=/log.txt")
+				c.mustRun(`find -path=/ -name="*.txt"`)
+				c.mustRun(fmt.Sprintf("rep -id=%s -path=%s -name=file -path_file_ls=/log.txt", id, filepath.Join(dir, fmt.Sprintf("log%d.txt", i))))
+			}
+		}()
+	}
+	wg.Wait()
+
+	checkFsck(t, admin, id)
+	log := admin.mustRun("cat -file1=/log.txt")
+	if got := strings.Count(log, "+linea"); got != 20 {
+		t.Errorf("log.txt tiene %d líneas agregadas, se esperaban 20:\n%s", got, log)
+	}
+	users := admin.mustRun("cat -file1=/users.txt")
+	for i := range 4 {
+		if !strings.Contains(users, fmt.Sprintf(",devs,u%d,", i)) {
+			t.Errorf("falta el usuario u%d en users.txt:\n%s", i, users)
+		}
+	}
+	admin.mustRun("journaling -id=" + id)
+}
+
+// Se crean, montan, usan y desmontan varios discos a la vez
+func TestParallelDisks(t *testing.T) {
+	app, dir := newTestServer(t)
+
+	const disks = 4
+	ids := make([]string, disks)
+	var wg sync.WaitGroup
+	for i := range disks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := &testClient{t: t, app: app}
+			ids[i] = setupPartition(c, filepath.Join(dir, fmt.Sprintf("p%d.mia", i)), "2fs")
+			c.mustRun("login -user=root -pass=123 -id=" + ids[i])
+			for j := range 5 {
+				c.mustRun(fmt.Sprintf("mkdir -path=/d%d", j))
+			}
+			c.mustRun("logout")
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			t.Fatalf("IDs de montaje repetidos o vacíos: %v", ids)
+		}
+		seen[id] = true
+	}
+
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := &testClient{t: t, app: app}
+			summary := checkFsck(t, c, id)
+			if !strings.Contains(summary, "6 carpetas") {
+				t.Errorf("se esperaban 6 carpetas en %s:\n%s", id, summary)
+			}
+			c.mustRun("sync -id=" + id + "\nunmount -id=" + id)
+		}()
+	}
+	wg.Wait()
+
+	if mounted := stores.MountedPartitions(); len(mounted) != 0 {
+		t.Errorf("quedaron particiones montadas: %v", mounted)
+	}
+}
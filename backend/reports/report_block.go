@@ -2,26 +2,14 @@ package reports
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
 )
 
-func ReportBlock(sb *structures.SuperBlock, diskPath string) (string, error) {
-	file, err := os.Open(diskPath)
-	if err != nil {
-		return "", fmt.Errorf("error al abrir disco: %v", err)
-	}
-	defer file.Close()
-
+func ReportBlock(sb *structures.SuperBlock, disk *structures.Disk) (string, error) {
 	// Leer bitmap de inodos
-	_, err = file.Seek(int64(sb.S_bm_inode_start), 0)
-	if err != nil {
-		return "", fmt.Errorf("error buscando bitmap de inodos: %v", err)
-	}
-	bmInode := make([]byte, sb.S_inodes_count)
-	_, err = file.Read(bmInode)
+	bmInode, err := sb.InodeBitmap(disk)
 	if err != nil {
 		return "", fmt.Errorf("error leyendo bitmap de inodos: %v", err)
 	}
@@ -31,7 +19,6 @@ func ReportBlock(sb *structures.SuperBlock, diskPath string) (string, error) {
 	sbBuilder.WriteString("  node [shape=plaintext]\n")
 
 	inodeSize := int(sb.S_inode_size)
-	blockSize := int(sb.S_block_size)
 	blockCounter := 0
 
 	for i := int32(0); i < sb.S_inodes_count; i++ {
@@ -40,24 +27,42 @@ func ReportBlock(sb *structures.SuperBlock, diskPath string) (string, error) {
 		}
 
 		inode := &structures.Inode{}
-		err := inode.Deserialize(diskPath, int64(sb.S_inode_start+(i*int32(inodeSize))))
+		err := inode.Deserialize(disk, int64(sb.S_inode_start+(i*int32(inodeSize))))
 		if err != nil {
 			return "", fmt.Errorf("error deserializando inodo %d: %v", i, err)
 		}
 
 		var prevBlock int = -1
-		for j := 0; j < 12; j++ {
-			blockNum := inode.I_block[j]
-			if blockNum == -1 {
-				continue
+		err = sb.WalkInodeBlocks(disk, inode, func(blockNum int32, level int) error {
+			blockOffset := sb.BlockOffset(blockNum)
+
+			if level > 0 { // Bloque de apuntadores
+				pointerBlock := sb.NewPointerBlock()
+				err := pointerBlock.Deserialize(disk, blockOffset)
+				if err != nil {
+					return fmt.Errorf("error deserializando bloque apuntadores %d: %v", blockNum, err)
+				}
+				pointers := make([]string, len(pointerBlock.P_pointers))
+				for k, pointer := range pointerBlock.P_pointers {
+					pointers[k] = fmt.Sprintf("%d", pointer)
+				}
+				sbBuilder.WriteString(fmt.Sprintf("  block%d [label=<<TABLE BORDER=\"0\" CELLBORDER=\"1\" CELLSPACING=\"0\">\n", blockCounter))
+				sbBuilder.WriteString(fmt.Sprintf("    <TR><TD>Bloque Apuntadores %d (nivel %d)</TD></TR>\n", blockNum, level))
+				sbBuilder.WriteString(fmt.Sprintf("    <TR><TD>%s</TD></TR>\n", strings.Join(pointers, ", ")))
+				sbBuilder.WriteString("  </TABLE>>];\n")
+				if prevBlock != -1 {
+					sbBuilder.WriteString(fmt.Sprintf("  block%d -> block%d;\n", prevBlock, blockCounter))
+				}
+				prevBlock = blockCounter
+				blockCounter++
+				return nil
 			}
-			blockOffset := int64(sb.S_block_start + (blockNum * int32(blockSize)))
 
 			if inode.I_type[0] == '0' { // Carpeta
-				folderBlock := &structures.FolderBlock{}
-				err = folderBlock.Deserialize(diskPath, blockOffset)
+				folderBlock := sb.NewFolderBlock()
+				err := folderBlock.Deserialize(disk, blockOffset)
 				if err != nil {
-					return "", fmt.Errorf("error deserializando bloque carpeta %d: %v", blockNum, err)
+					return fmt.Errorf("error deserializando bloque carpeta %d: %v", blockNum, err)
 				}
 				hasContent := false
 				for _, content := range folderBlock.B_content {
@@ -87,10 +92,10 @@ func ReportBlock(sb *structures.SuperBlock, diskPath string) (string, error) {
 					blockCounter++
 				}
 			} else if inode.I_type[0] == '1' { // Archivo
-				fileBlock := &structures.FileBlock{}
-				err = fileBlock.Deserialize(diskPath, blockOffset)
+				fileBlock := sb.NewFileBlock()
+				err := fileBlock.Deserialize(disk, blockOffset)
 				if err != nil {
-					return "", fmt.Errorf("error deserializando bloque archivo %d: %v", blockNum, err)
+					return fmt.Errorf("error deserializando bloque archivo %d: %v", blockNum, err)
 				}
 				content := strings.TrimRight(string(fileBlock.B_content[:]), "\x00")
 				if content != "" {
@@ -109,6 +114,10 @@ func ReportBlock(sb *structures.SuperBlock, diskPath string) (string, error) {
 					blockCounter++
 				}
 			}
+			return nil
+		})
+		if err != nil {
+			return "", err
 		}
 	}
 
@@ -7,20 +7,8 @@ import (
 	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
 )
 
-func ReportBMBlock(sb *structures.SuperBlock, diskPath string, outputPath string) error {
-	file, err := os.Open(diskPath)
-	if err != nil {
-		return fmt.Errorf("error abriendo disco: %v", err)
-	}
-	defer file.Close()
-
-	_, err = file.Seek(int64(sb.S_bm_block_start), 0)
-	if err != nil {
-		return fmt.Errorf("error buscando bitmap de bloques: %v", err)
-	}
-
-	buffer := make([]byte, sb.S_blocks_count)
-	_, err = file.Read(buffer)
+func ReportBMBlock(sb *structures.SuperBlock, disk *structures.Disk, outputPath string) error {
+	buffer, err := sb.BlockBitmap(disk)
 	if err != nil {
 		return fmt.Errorf("error leyendo bitmap de bloques: %v", err)
 	}
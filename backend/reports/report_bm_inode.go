@@ -10,7 +10,7 @@ import (
 )
 
 // ReportBMInode genera un reporte del bitmap de inodos en formato texto
-func ReportBMInode(sb *structures.SuperBlock, diskPath, outputPath string) error {
+func ReportBMInode(sb *structures.SuperBlock, disk *structures.Disk, outputPath string) error {
 	if sb == nil {
 		return fmt.Errorf("superbloque no proporcionado")
 	}
@@ -21,32 +21,20 @@ func ReportBMInode(sb *structures.SuperBlock, diskPath, outputPath string) error
 		return fmt.Errorf("error creando directorios padre: %v", err)
 	}
 
-	file, err := os.Open(diskPath)
+	totalInodes := sb.S_inodes_count // Solo S_inodes_count, no sumamos S_free_inodes_count
+
+	bitmap, err := sb.InodeBitmap(disk)
 	if err != nil {
-		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
+		return fmt.Errorf("error al leer el bitmap de inodos: %v", err)
 	}
-	defer file.Close()
-
-	totalInodes := sb.S_inodes_count // Solo S_inodes_count, no sumamos S_free_inodes_count
 
 	var bitmapContent strings.Builder
 	for i := int32(0); i < totalInodes; i++ {
-		_, err := file.Seek(int64(sb.S_bm_inode_start)+int64(i), 0)
-		if err != nil {
-			return fmt.Errorf("error al establecer el puntero en el archivo: %v", err)
-		}
-
-		char := make([]byte, 1)
-		_, err = file.Read(char)
-		if err != nil {
-			return fmt.Errorf("error al leer el byte del archivo: %v", err)
-		}
-
-		if char[0] != '0' && char[0] != '1' {
-			return fmt.Errorf("carácter inválido en bitmap: %c (posición %d)", char[0], i)
+		if bitmap[i] != '0' && bitmap[i] != '1' {
+			return fmt.Errorf("carácter inválido en bitmap: %c (posición %d)", bitmap[i], i)
 		}
 
-		bitmapContent.WriteByte(char[0])
+		bitmapContent.WriteByte(bitmap[i])
 		if (i+1)%20 == 0 && i != totalInodes-1 {
 			bitmapContent.WriteString("\n")
 		}
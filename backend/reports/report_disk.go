@@ -1,7 +1,9 @@
 package reports
 
 import (
+	"encoding/binary"
 	"fmt"
+	"sort"
 	"strings"
 
 	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
@@ -14,12 +16,18 @@ func ReportDisk(mbr *structures.MBR, diskPath string) (string, error) {
 	sb.WriteString("  node [shape=record];\n")
 	sb.WriteString("  disk [label=\"{MBR|")
 
-	totalSize := float64(mbr.Mbr_size)
-	start := int32(0)
+	// Recorrer las particiones en el orden en que están en el disco, no en el de la tabla
+	var parts []structures.Partition
 	for _, part := range mbr.Mbr_partitions {
-		if part.Part_size <= 0 || part.Part_status[0] == 'N' {
-			continue
+		if part.Part_size > 0 && part.Part_status[0] != 'N' {
+			parts = append(parts, part)
 		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Part_start < parts[j].Part_start })
+
+	totalSize := float64(mbr.Mbr_size)
+	start := int32(binary.Size(mbr))
+	for _, part := range parts {
 		percent := (float64(part.Part_size) / totalSize) * 100
 		if part.Part_start > start {
 			freePercent := (float64(part.Part_start-start) / totalSize) * 100
@@ -2,7 +2,6 @@ package reports
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
@@ -22,11 +21,11 @@ func ReportEBR(mbr *structures.MBR, diskPath string) (string, error) {
 	}
 
 	// Abrir el archivo para leer los EBRs
-	file, err := os.Open(diskPath)
+	image, err := structures.OpenImage(diskPath)
 	if err != nil {
 		return "", fmt.Errorf("error al abrir disco: %v", err)
 	}
-	defer file.Close()
+	defer image.Close()
 
 	// Iniciar el grafo DOT
 	var sbBuilder strings.Builder
@@ -39,7 +38,7 @@ func ReportEBR(mbr *structures.MBR, diskPath string) (string, error) {
 	ebrCount := 0
 	for currentOffset != -1 {
 		ebr := &structures.EBR{}
-		err = ebr.Deserialize(file, currentOffset)
+		err = ebr.Deserialize(image, currentOffset)
 		if err != nil {
 			return "", fmt.Errorf("error deserializando EBR en offset %d: %v", currentOffset, err)
 		}
@@ -2,7 +2,6 @@ package reports
 
 import (
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -10,20 +9,9 @@ import (
 )
 
 // ReportInode genera un reporte de un inodo y lo guarda en la ruta especificada
-func ReportInode(sb *structures.SuperBlock, diskPath string) (string, error) {
-	file, err := os.Open(diskPath)
-	if err != nil {
-		return "", fmt.Errorf("error abriendo disco: %v", err)
-	}
-	defer file.Close()
-
+func ReportInode(sb *structures.SuperBlock, disk *structures.Disk) (string, error) {
 	// Leer bitmap de inodos para filtrar los ocupados
-	_, err = file.Seek(int64(sb.S_bm_inode_start), 0)
-	if err != nil {
-		return "", fmt.Errorf("error buscando bitmap de inodos: %v", err)
-	}
-	bmInode := make([]byte, sb.S_inodes_count)
-	_, err = file.Read(bmInode)
+	bmInode, err := sb.InodeBitmap(disk)
 	if err != nil {
 		return "", fmt.Errorf("error leyendo bitmap de inodos: %v", err)
 	}
@@ -39,7 +27,7 @@ func ReportInode(sb *structures.SuperBlock, diskPath string) (string, error) {
 		}
 
 		inode := &structures.Inode{}
-		err := inode.Deserialize(diskPath, int64(sb.S_inode_start+(i*sb.S_inode_size)))
+		err := inode.Deserialize(disk, int64(sb.S_inode_start+(i*sb.S_inode_size)))
 		if err != nil {
 			return "", fmt.Errorf("error deserializando inodo %d: %v", i, err)
 		}
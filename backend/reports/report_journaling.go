@@ -0,0 +1,37 @@
+package reports
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+)
+
+func ReportJournaling(sb *structures.SuperBlock, disk *structures.Disk) (string, error) {
+	entries, err := sb.ReadJournal(disk)
+	if err != nil {
+		return "", err
+	}
+
+	var journalBuilder strings.Builder
+	journalBuilder.WriteString("digraph G {\n")
+	journalBuilder.WriteString("  node [shape=plaintext]\n")
+	journalBuilder.WriteString("  tbl [label=<<TABLE BORDER=\"0\" CELLBORDER=\"1\" CELLSPACING=\"0\">\n")
+	journalBuilder.WriteString("    <TR><TD COLSPAN=\"5\">REPORTE JOURNALING</TD></TR>\n")
+	journalBuilder.WriteString("    <TR><TD>#</TD><TD>Operación</TD><TD>Ruta</TD><TD>Contenido</TD><TD>Fecha</TD></TR>\n")
+	for _, entry := range entries {
+		date := time.Unix(int64(entry.J_content.I_date), 0).Format("02/01/2006 15:04")
+		journalBuilder.WriteString(fmt.Sprintf("    <TR><TD>%d</TD><TD>%s</TD><TD>%s</TD><TD>%s</TD><TD>%s</TD></TR>\n",
+			entry.J_count,
+			html.EscapeString(entry.Operation()),
+			html.EscapeString(ifElse(entry.Path() == "", "-", entry.Path())),
+			html.EscapeString(ifElse(entry.Content() == "", "-", entry.Content())),
+			date))
+	}
+	journalBuilder.WriteString("  </TABLE>>];\n")
+	journalBuilder.WriteString("}\n")
+
+	return journalBuilder.String(), nil
+}
@@ -26,7 +26,11 @@ func ReportSB(sb *structures.SuperBlock) (string, error) {
 	sbBuilder.WriteString("  node [shape=plaintext]\n")
 	sbBuilder.WriteString("  tbl [label=<<TABLE BORDER=\"0\" CELLBORDER=\"1\" CELLSPACING=\"0\">\n")
 	sbBuilder.WriteString("    <TR><TD COLSPAN=\"2\">REPORTE SUPERBLOQUE</TD></TR>\n")
-	sbBuilder.WriteString(fmt.Sprintf("    <TR><TD>S_filesystem_type</TD><TD>%d</TD></TR>\n", sb.S_filesystem_type))
+	bitmapFormat := "bytes"
+	if sb.PackedBitmaps() {
+		bitmapFormat = "packed"
+	}
+	sbBuilder.WriteString(fmt.Sprintf("    <TR><TD>S_filesystem_type</TD><TD>%d (bitmaps: %s)</TD></TR>\n", sb.FsType(), bitmapFormat))
 	sbBuilder.WriteString(fmt.Sprintf("    <TR><TD>S_inodes_count</TD><TD>%d</TD></TR>\n", sb.S_inodes_count))
 	sbBuilder.WriteString(fmt.Sprintf("    <TR><TD>S_blocks_count</TD><TD>%d</TD></TR>\n", sb.S_blocks_count))
 	sbBuilder.WriteString(fmt.Sprintf("    <TR><TD>S_free_inodes_count</TD><TD>%d</TD></TR>\n", sb.S_free_inodes_count))
@@ -2,25 +2,17 @@ package reports
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
 )
 
-func ReportTree(sb *structures.SuperBlock, diskPath string) (string, error) {
-	file, err := os.Open(diskPath)
-	if err != nil {
-		return "", fmt.Errorf("error al abrir disco: %v", err)
-	}
-	defer file.Close()
-
+func ReportTree(sb *structures.SuperBlock, disk *structures.Disk) (string, error) {
 	var sbBuilder strings.Builder
 	sbBuilder.WriteString("digraph Tree {\n")
 	sbBuilder.WriteString("  node [shape=box]\n")
 
 	inodeSize := int(sb.S_inode_size)
-	blockSize := int(sb.S_block_size)
 	processedInodes := make(map[int32]bool)
 
 	var buildTree func(inodoNum int32, parentPath string) error
@@ -31,7 +23,7 @@ func ReportTree(sb *structures.SuperBlock, diskPath string) (string, error) {
 		processedInodes[inodoNum] = true
 
 		inode := &structures.Inode{}
-		err := inode.Deserialize(diskPath, int64(sb.S_inode_start+(inodoNum*int32(inodeSize))))
+		err := inode.Deserialize(disk, int64(sb.S_inode_start+(inodoNum*int32(inodeSize))))
 		if err != nil {
 			return fmt.Errorf("error deserializando inodo %d: %v", inodoNum, err)
 		}
@@ -41,13 +33,26 @@ func ReportTree(sb *structures.SuperBlock, diskPath string) (string, error) {
 			currentPath = fmt.Sprintf("\"%s\"", parentPath)
 		}
 
+		// Bloques de datos del inodo; los bloques de apuntadores se muestran como nodos propios
+		var blocks []int32
+		err = sb.WalkInodeBlocks(disk, inode, func(blockNum int32, level int) error {
+			if level == 0 {
+				blocks = append(blocks, blockNum)
+				return nil
+			}
+			pointerNode := fmt.Sprintf("\"apuntadores %d\"", blockNum)
+			sbBuilder.WriteString(fmt.Sprintf("  %s [label=\"Apuntadores %d (nivel %d)\" shape=note]\n", pointerNode, blockNum, level))
+			sbBuilder.WriteString(fmt.Sprintf("  %s -> %s [style=dashed]\n", currentPath, pointerNode))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error leyendo bloques del inodo %d: %v", inodoNum, err)
+		}
+
 		if inode.I_type[0] == '0' { // Carpeta
-			for _, blockNum := range inode.I_block[:12] { // Procesar todos los bloques directos
-				if blockNum == -1 {
-					break
-				}
-				folderBlock := &structures.FolderBlock{}
-				err = folderBlock.Deserialize(diskPath, int64(sb.S_block_start+(blockNum*int32(blockSize))))
+			for _, blockNum := range blocks {
+				folderBlock := sb.NewFolderBlock()
+				err = folderBlock.Deserialize(disk, sb.BlockOffset(blockNum))
 				if err != nil {
 					return fmt.Errorf("error deserializando bloque carpeta %d: %v", blockNum, err)
 				}
@@ -70,12 +75,9 @@ func ReportTree(sb *structures.SuperBlock, diskPath string) (string, error) {
 				}
 			}
 		} else if inode.I_type[0] == '1' { // Archivo
-			for i, blockNum := range inode.I_block[:12] {
-				if blockNum == -1 {
-					break
-				}
-				fileBlock := &structures.FileBlock{}
-				err = fileBlock.Deserialize(diskPath, int64(sb.S_block_start+(blockNum*int32(blockSize))))
+			for i, blockNum := range blocks {
+				fileBlock := sb.NewFileBlock()
+				err = fileBlock.Deserialize(disk, sb.BlockOffset(blockNum))
 				if err != nil {
 					return fmt.Errorf("error deserializando bloque archivo %d: %v", blockNum, err)
 				}
@@ -88,7 +90,7 @@ func ReportTree(sb *structures.SuperBlock, diskPath string) (string, error) {
 		return nil
 	}
 
-	err = buildTree(0, "")
+	err := buildTree(0, "")
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,64 @@
+package stores
+
+import "sync"
+
+/*
+Candados de las imágenes de disco.
+
+El servidor atiende peticiones en paralelo, así que cada comando toma un candado antes
+de ejecutarse (ver analyzer.lockCommand):
+	- Los comandos que cambian los MBR/EBR o las particiones montadas (mkdisk, rmdisk,
+	  fdisk, mount, unmount) toman LockDisks: corren solos.
+	- Los comandos sobre una partición toman LockPartition: el candado compartido de las
+	  imágenes y el de la partición, exclusivo si el comando la modifica y compartido si
+	  solo la lee.
+
+Con el candado exclusivo de una partición, leer el superbloque, reservar inodos y bloques
+en los bitmaps y volver a escribir el superbloque ocurre sin que otro comando lo
+intercale. El superbloque se escribe con una sola escritura en la caché de páginas, que
+tiene su propio mutex, así que un lector nunca ve un superbloque escrito a medias.
+
+Orden de los candados: disksMu, después el de la partición. Los mutex internos
+(mountsMu, sessionsMu, la caché) se toman después y nunca al revés.
+*/
+
+var (
+	// disksMu es exclusivo para los comandos que cambian el conjunto de discos y particiones
+	disksMu sync.RWMutex
+
+	partitionLocksMu sync.Mutex
+	partitionLocks   = make(map[string]*sync.RWMutex)
+)
+
+// LockDisks toma el candado exclusivo de todas las imágenes y devuelve la función que lo libera
+func LockDisks() func() {
+	disksMu.Lock()
+	return disksMu.Unlock
+}
+
+// LockPartition toma el candado de la partición id, exclusivo si write es true, y devuelve
+// la función que lo libera
+func LockPartition(id string, write bool) func() {
+	disksMu.RLock()
+
+	partitionLocksMu.Lock()
+	lock, ok := partitionLocks[id]
+	if !ok {
+		lock = &sync.RWMutex{}
+		partitionLocks[id] = lock
+	}
+	partitionLocksMu.Unlock()
+
+	if write {
+		lock.Lock()
+		return func() {
+			lock.Unlock()
+			disksMu.RUnlock()
+		}
+	}
+	lock.RLock()
+	return func() {
+		lock.RUnlock()
+		disksMu.RUnlock()
+	}
+}
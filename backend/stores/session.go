@@ -0,0 +1,167 @@
+package stores
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+/*
+Sesiones.
+
+Cada cliente del servidor recibe un token (ver main.go) y cada token tiene su propia
+sesión, así que dos pestañas o dos usuarios no comparten el login. El token llega a los
+comandos en el context.Context de la petición; los comandos obtienen la sesión con
+SessionFromContext.
+
+Un token que no se usa durante SessionTimeout se descarta junto con su sesión.
+*/
+
+// SessionTimeout es el tiempo sin uso tras el que se descarta un token
+var SessionTimeout = 30 * time.Minute
+
+// Session representa una sesión activa
+type Session struct {
+	ID       string   // ID de la partición
+	Username string   // Nombre del usuario
+	UID      string   // ID del usuario
+	GID      string   // ID del grupo principal
+	Groups   []string // IDs de los grupos adicionales
+}
+
+// sessionEntry es la sesión de un token; una Session vacía indica que no hay login
+type sessionEntry struct {
+	session  Session
+	lastUsed time.Time
+}
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = make(map[string]*sessionEntry)
+)
+
+// sessionTokenKey es la clave del token de sesión en el context.Context
+type sessionTokenKey struct{}
+
+// WithSessionToken devuelve un contexto que lleva el token de sesión
+func WithSessionToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, sessionTokenKey{}, token)
+}
+
+// SessionToken devuelve el token de sesión del contexto, o una cadena vacía
+func SessionToken(ctx context.Context) string {
+	token, _ := ctx.Value(sessionTokenKey{}).(string)
+	return token
+}
+
+// NewSessionToken genera un token nuevo, todavía sin login
+func NewSessionToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	expireSessions(time.Now())
+	sessions[token] = &sessionEntry{lastUsed: time.Now()}
+	return token, nil
+}
+
+// TouchSession indica si el token existe y no ha expirado, y renueva su tiempo de uso
+func TouchSession(token string) bool {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	return lookupSession(token) != nil
+}
+
+// SessionFromContext devuelve una copia de la sesión del token del contexto.
+// Si no hay login, la sesión está vacía (ID == "").
+func SessionFromContext(ctx context.Context) *Session {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	if entry := lookupSession(SessionToken(ctx)); entry != nil {
+		session := entry.session
+		return &session
+	}
+	return &Session{}
+}
+
+// SetSession guarda la sesión del token del contexto
+func SetSession(ctx context.Context, session Session) error {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	entry := lookupSession(SessionToken(ctx))
+	if entry == nil {
+		return errors.New("token de sesión inválido o expirado")
+	}
+	entry.session = session
+	return nil
+}
+
+// ClearSession cierra la sesión del token del contexto; el token sigue siendo válido
+func ClearSession(ctx context.Context) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	if entry := lookupSession(SessionToken(ctx)); entry != nil {
+		entry.session = Session{}
+	}
+}
+
+// DeleteSessionToken descarta el token y su sesión
+func DeleteSessionToken(token string) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	delete(sessions, token)
+}
+
+// ClearPartitionSessions cierra todas las sesiones iniciadas en la partición id
+func ClearPartitionSessions(id string) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	for _, entry := range sessions {
+		if entry.session.ID == id {
+			entry.session = Session{}
+		}
+	}
+}
+
+// UpdateUserSessions cambia el grupo principal y los grupos adicionales de las sesiones de username
+// en la partición id, para que los permisos usen los grupos nuevos sin volver a iniciar sesión
+func UpdateUserSessions(id, username, gid string, groups []string) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	for _, entry := range sessions {
+		if entry.session.ID == id && entry.session.Username == username {
+			entry.session.GID = gid
+			entry.session.Groups = slices.Clone(groups)
+		}
+	}
+}
+
+// lookupSession devuelve la entrada vigente del token y renueva su tiempo de uso.
+// Se llama con sessionsMu tomado.
+func lookupSession(token string) *sessionEntry {
+	now := time.Now()
+	expireSessions(now)
+	entry, ok := sessions[token]
+	if !ok {
+		return nil
+	}
+	entry.lastUsed = now
+	return entry
+}
+
+// expireSessions descarta los tokens sin uso durante SessionTimeout. Se llama con sessionsMu tomado.
+func expireSessions(now time.Time) {
+	for token, entry := range sessions {
+		if now.Sub(entry.lastUsed) > SessionTimeout {
+			delete(sessions, token)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package stores
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
+	utils "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/utils"
+)
+
+// StateFileEnv es la variable de entorno que indica dónde guardar el estado de montaje
+const StateFileEnv = "MIA_STATE_FILE"
+
+// defaultStateFile es el archivo de estado usado si StateFileEnv no está definida
+const defaultStateFile = "mia_state.json"
+
+// mountState es el contenido del archivo de estado
+type mountState struct {
+	Mounted map[string]string `json:"mounted"` // ID de partición -> path del disco
+	utils.LetterState
+}
+
+/*
+	{
+	  "mounted": {"671A": "/home/Disco1.mia"},
+	  "letters": {"/home/Disco1.mia": "A"},
+	  "correlatives": {"/home/Disco1.mia": 1},
+	  "next_letter": 1
+	}
+*/
+
+// StateFilePath devuelve la ruta del archivo de estado
+func StateFilePath() string {
+	if path := os.Getenv(StateFileEnv); path != "" {
+		return path
+	}
+	return defaultStateFile
+}
+
+// SaveState guarda las particiones montadas y la asignación de IDs en el archivo de estado
+func SaveState() error {
+	state := mountState{
+		Mounted:     MountedPartitions(),
+		LetterState: utils.GetLetterState(),
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error al codificar el estado: %v", err)
+	}
+
+	// Escribir en un temporal y renombrar para no dejar el archivo a medias
+	path := StateFilePath()
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return fmt.Errorf("error al crear la carpeta del estado: %v", err)
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error al escribir el estado: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error al guardar el estado: %v", err)
+	}
+	return nil
+}
+
+// LoadState restaura el estado guardado y lo concilia con los Part_id de los discos:
+// se descartan los montajes que el disco ya no registra y se recuperan los que
+// el disco marca como montados aunque falten en el archivo.
+func LoadState() error {
+	data, err := os.ReadFile(StateFilePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error al leer el estado: %v", err)
+	}
+
+	var state mountState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("error al decodificar el estado: %v", err)
+	}
+	if state.Letters == nil {
+		state.Letters = make(map[string]string)
+	}
+	if state.Correlatives == nil {
+		state.Correlatives = make(map[string]int)
+	}
+	utils.SetLetterState(state.LetterState)
+
+	// Revisar todos los discos conocidos, tengan o no montajes guardados
+	paths := make(map[string]bool)
+	for path := range state.Letters {
+		paths[path] = true
+	}
+	for _, path := range state.Mounted {
+		paths[path] = true
+	}
+
+	mountsMu.Lock()
+	mountedPartitions = make(map[string]string)
+	mountsMu.Unlock()
+	for path := range paths {
+		ids, err := mountedIDs(path)
+		if err != nil {
+			continue // El disco ya no existe o no se puede leer
+		}
+		for _, id := range ids {
+			RestoreMount(path, id)
+		}
+	}
+	return SaveState()
+}
+
+// RestoreMount vuelve a registrar una partición que el disco marca como montada con el ID indicado.
+// Devuelve false si el ID no corresponde al disco o ya está en uso.
+func RestoreMount(path string, id string) bool {
+	mountsMu.Lock()
+	defer mountsMu.Unlock()
+
+	if _, exists := mountedPartitions[id]; exists {
+		return false
+	}
+	if !strings.HasPrefix(id, Carnet) || len(id) < len(Carnet)+2 {
+		return false
+	}
+	letter := id[len(id)-1:]
+	correlative, err := strconv.Atoi(id[len(Carnet) : len(id)-1])
+	if err != nil {
+		return false
+	}
+	if !utils.ReserveLetterAndPartitionCorrelative(path, letter, correlative) {
+		return false
+	}
+	mountedPartitions[id] = path
+	return true
+}
+
+// mountedIDs devuelve los ID de las particiones que el disco marca como montadas
+func mountedIDs(path string) ([]string, error) {
+	var mbr structures.MBR
+	if err := mbr.Deserialize(path); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	var extPartition *structures.Partition
+	for _, p := range mbr.Mbr_partitions {
+		if p.Part_type[0] == 'E' && p.Part_status[0] != 'N' {
+			extPartition = &p
+			continue
+		}
+		if p.Part_status[0] == '1' {
+			ids = append(ids, strings.Trim(string(p.Part_id[:]), "\x00"))
+		}
+	}
+	if extPartition == nil {
+		return ids, nil
+	}
+
+	image, err := structures.OpenImage(path)
+	if err != nil {
+		return nil, err
+	}
+	defer image.Close()
+
+	var currentEBR structures.EBR
+	currentOffset := int64(extPartition.Part_start)
+	for {
+		if err := currentEBR.Deserialize(image, currentOffset); err != nil {
+			return ids, nil
+		}
+		if currentEBR.Part_status[0] == '1' {
+			ids = append(ids, strings.Trim(string(currentEBR.Part_id[:]), "\x00"))
+		}
+		if currentEBR.Part_next == -1 {
+			return ids, nil
+		}
+		currentOffset = int64(currentEBR.Part_next)
+	}
+}
@@ -1,10 +1,12 @@
 package stores
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
-	"os"
+	"maps"
 	"strings"
+	"sync"
 
 	structures "github.com/MarceJua/MIA_1S2025_P1_202010367/backend/structures"
 )
@@ -12,49 +14,109 @@ import (
 // Carnet de estudiante
 const Carnet string = "67" // 202010367
 
-// Session representa una sesión activa
-type Session struct {
-	ID       string // ID de la partición
-	Username string // Nombre del usuario
-	UID      string // ID del usuario
-	GID      string // ID del grupo
+var (
+	// mountsMu protege mountedPartitions y mountedDisks; los comandos pueden consultarlos en paralelo (ver locks.go)
+	mountsMu sync.Mutex
+
+	// mountedPartitions guarda el path del disco de cada partición montada, indexado por ID
+	mountedPartitions = make(map[string]string)
+
+	// mountedDisks guarda el Disk abierto de cada partición montada, indexado por ID
+	mountedDisks = make(map[string]*structures.Disk)
+)
+
+// MountedPath devuelve el path del disco de la partición montada con el id especificado
+func MountedPath(id string) (string, bool) {
+	mountsMu.Lock()
+	defer mountsMu.Unlock()
+	path, ok := mountedPartitions[id]
+	return path, ok
+}
+
+// MountedPartitions devuelve una copia de las particiones montadas (ID -> path del disco)
+func MountedPartitions() map[string]string {
+	mountsMu.Lock()
+	defer mountsMu.Unlock()
+	return maps.Clone(mountedPartitions)
 }
 
-// CurrentSession almacena la sesión actual
-var CurrentSession Session
+// AddMount registra la partición id del disco path como montada
+func AddMount(id string, path string) {
+	mountsMu.Lock()
+	defer mountsMu.Unlock()
+	mountedPartitions[id] = path
+}
+
+// RemoveMount quita la partición id de las particiones montadas
+func RemoveMount(id string) {
+	mountsMu.Lock()
+	defer mountsMu.Unlock()
+	delete(mountedPartitions, id)
+}
 
-// Declaración de variables globales
-var MountedPartitions = make(map[string]string)
+// GetMountedDisk devuelve el Disk de la partición montada con el id especificado.
+// Se abre la primera vez que se pide y se mantiene abierto hasta desmontar la partición.
+func GetMountedDisk(id string) (*structures.Disk, error) {
+	mountsMu.Lock()
+	defer mountsMu.Unlock()
 
-func GetMountedPartitionRep(id string) (*structures.MBR, *structures.SuperBlock, string, error) {
-	path, exists := MountedPartitions[id]
+	if disk, ok := mountedDisks[id]; ok {
+		return disk, nil
+	}
+	path, exists := mountedPartitions[id]
 	if !exists {
-		return nil, nil, "", errors.New("partición no montada")
+		return nil, errors.New("partición no montada")
 	}
 
-	var mbr structures.MBR
-	if err := mbr.Deserialize(path); err != nil {
-		return nil, nil, "", fmt.Errorf("error deserializando MBR: %v", err)
+	start, size, err := findPartitionRange(path, id)
+	if err != nil {
+		return nil, err
+	}
+	disk, err := structures.OpenDisk(path, start, size)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo disco: %v", err)
 	}
+	mountedDisks[id] = disk
+	return disk, nil
+}
 
-	// Buscar partición primaria
-	for _, p := range mbr.Mbr_partitions {
-		if strings.Trim(string(p.Part_id[:]), "\x00") == id {
-			var sb structures.SuperBlock
-			err := sb.Deserialize(path, int64(p.Part_start))
-			if err != nil {
-				return &mbr, nil, path, nil // Devolver sin superbloque si falla (para mbr/disk)
-			}
-			return &mbr, &sb, path, nil
-		}
+// SyncMountedDisk escribe en el disco las páginas modificadas de la partición montada con el id especificado
+func SyncMountedDisk(id string) (int, error) {
+	mountsMu.Lock()
+	disk, ok := mountedDisks[id]
+	mountsMu.Unlock()
+	if !ok {
+		return 0, nil
 	}
+	return disk.Sync()
+}
 
-	// Buscar en particiones lógicas
-	file, err := os.OpenFile(path, os.O_RDWR, 0644)
-	if err != nil {
-		return nil, nil, "", fmt.Errorf("error abriendo disco: %v", err)
+// CloseMountedDisk escribe las páginas pendientes y cierra el Disk de la partición con el id especificado, si está abierto
+func CloseMountedDisk(id string) error {
+	mountsMu.Lock()
+	disk, ok := mountedDisks[id]
+	delete(mountedDisks, id)
+	mountsMu.Unlock()
+	if !ok {
+		return nil
+	}
+	if _, err := disk.Sync(); err != nil {
+		disk.Close()
+		return err
+	}
+	return disk.Close()
+}
+
+// findPartitionRange busca la partición primaria o lógica con el id indicado y devuelve su inicio y tamaño.
+// En las lógicas el rango empieza después de su EBR, para que el sistema de archivos no lo sobrescriba.
+func findPartitionRange(path string, id string) (int64, int64, error) {
+	var mbr structures.MBR
+	if err := mbr.Deserialize(path); err != nil {
+		return 0, 0, fmt.Errorf("error deserializando MBR: %v", err)
+	}
+	if partition, _ := mbr.GetPartitionByID(id); partition != nil {
+		return int64(partition.Part_start), int64(partition.Part_size), nil
 	}
-	defer file.Close()
 
 	var extPartition *structures.Partition
 	for _, p := range mbr.Mbr_partitions {
@@ -64,57 +126,79 @@ func GetMountedPartitionRep(id string) (*structures.MBR, *structures.SuperBlock,
 		}
 	}
 	if extPartition == nil {
-		return &mbr, nil, path, nil // Sin extendida, devolvemos solo MBR
+		return 0, 0, fmt.Errorf("partición %s no encontrada en el disco", id)
 	}
 
-	var currentEBR structures.EBR
-	currentOffset := int64(extPartition.Part_start)
-	fileInfo, err := file.Stat()
+	image, err := structures.OpenImage(path)
 	if err != nil {
-		return nil, nil, "", fmt.Errorf("error obteniendo tamaño del archivo: %v", err)
+		return 0, 0, fmt.Errorf("error abriendo disco: %v", err)
 	}
-	fileSize := fileInfo.Size()
+	defer image.Close()
 
-	for currentOffset < fileSize {
-		if err := currentEBR.Deserialize(file, currentOffset); err != nil {
-			return nil, nil, "", fmt.Errorf("error leyendo EBR en offset %d: %v", currentOffset, err)
+	var currentEBR structures.EBR
+	currentOffset := int64(extPartition.Part_start)
+	for {
+		if err := currentEBR.Deserialize(image, currentOffset); err != nil {
+			return 0, 0, fmt.Errorf("error leyendo EBR en offset %d: %v", currentOffset, err)
 		}
 		if strings.Trim(string(currentEBR.Part_id[:]), "\x00") == id {
-			var sb structures.SuperBlock
-			err := sb.Deserialize(path, int64(currentEBR.Part_start))
-			if err != nil {
-				return &mbr, nil, path, nil // Sin superbloque si falla
-			}
-			return &mbr, &sb, path, nil
+			ebrSize := int64(binary.Size(currentEBR))
+			return int64(currentEBR.Part_start) + ebrSize, int64(currentEBR.Part_size) - ebrSize, nil
 		}
 		if currentEBR.Part_next == -1 {
-			break
+			return 0, 0, fmt.Errorf("partición %s no encontrada en el disco", id)
 		}
 		currentOffset = int64(currentEBR.Part_next)
 	}
+}
+
+// GetMountedPartitionRep obtiene el MBR del disco y, si la partición está formateada, su SuperBlock
+func GetMountedPartitionRep(id string) (*structures.MBR, *structures.SuperBlock, *structures.Disk, error) {
+	disk, err := GetMountedDisk(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-	return &mbr, nil, path, nil // Si no se encuentra, devolvemos solo MBR
+	var mbr structures.MBR
+	if err := mbr.Deserialize(disk.Path); err != nil {
+		return nil, nil, nil, fmt.Errorf("error deserializando MBR: %v", err)
+	}
+
+	var sb structures.SuperBlock
+	if err := sb.Deserialize(disk, disk.Start()); err != nil || sb.S_magic != 0xEF53 {
+		return &mbr, nil, disk, nil // Devolver sin superbloque si no está formateada (para mbr/disk)
+	}
+	return &mbr, &sb, disk, nil
 }
 
 // GetMountedPartitionSuperblock obtiene el SuperBlock de la partición montada con el id especificado
-func GetMountedPartitionSuperblock(id string) (*structures.SuperBlock, *structures.Partition, string, error) {
-	path := MountedPartitions[id]
+func GetMountedPartitionSuperblock(id string) (*structures.SuperBlock, *structures.Partition, *structures.Disk, error) {
+	path, _ := MountedPath(id)
 	if path == "" {
-		return nil, nil, "", errors.New("la partición no está montada")
+		return nil, nil, nil, errors.New("la partición no está montada")
 	}
-	var mbr structures.MBR
-	err := mbr.Deserialize(path)
+	disk, err := GetMountedDisk(id)
 	if err != nil {
-		return nil, nil, "", err
+		return nil, nil, nil, err
 	}
-	partition, err := mbr.GetPartitionByID(id)
+	var mbr structures.MBR
+	if err := mbr.Deserialize(path); err != nil {
+		return nil, nil, nil, err
+	}
+	partition, _ := mbr.GetPartitionByID(id)
 	if partition == nil {
-		return nil, nil, "", err
+		// Partición lógica: se describe con el rango de su Disk
+		partition = &structures.Partition{
+			Part_status: [1]byte{'1'},
+			Part_type:   [1]byte{'L'},
+			Part_start:  int32(disk.Start()),
+			Part_size:   int32(disk.Size()),
+		}
+		copy(partition.Part_id[:], id)
 	}
 	var sb structures.SuperBlock
-	err = sb.Deserialize(path, int64(partition.Part_start))
-	if err != nil {
-		return nil, nil, "", err
+	if err := sb.Deserialize(disk, int64(partition.Part_start)); err != nil {
+		return nil, nil, nil, err
 	}
-	return &sb, partition, path, nil
+	return &sb, partition, disk, nil
 }
@@ -0,0 +1,183 @@
+package structures
+
+import (
+	"fmt"
+)
+
+/*
+Asignador de inodos y bloques.
+
+S_first_ino y S_first_blo funcionan como cursores: cada búsqueda empieza en el cursor
+y da la vuelta al final del bitmap (next-fit). Al liberar una entrada por debajo del
+cursor, el cursor retrocede hasta ella, de modo que los huecos se reutilizan primero
+(first-fit). El bitmap se lee una sola vez por operación, sin importar cuántas
+entradas se reserven o liberen.
+*/
+
+// AllocInode reserva un inodo libre
+func (sb *SuperBlock) AllocInode(disk *Disk) (int32, error) {
+	inodes, err := sb.allocate(disk, sb.S_bm_inode_start, sb.S_inodes_count, &sb.S_first_ino, &sb.S_free_inodes_count, 1)
+	if err != nil {
+		return -1, fmt.Errorf("no hay inodos libres disponibles: %v", err)
+	}
+	return inodes[0], nil
+}
+
+// AllocBlock reserva un bloque libre
+func (sb *SuperBlock) AllocBlock(disk *Disk) (int32, error) {
+	blocks, err := sb.AllocBlocks(disk, 1)
+	if err != nil {
+		return -1, err
+	}
+	return blocks[0], nil
+}
+
+// AllocBlocks reserva count bloques. Intenta primero un tramo contiguo a partir del cursor;
+// si no existe, toma los primeros count bloques libres que encuentre.
+func (sb *SuperBlock) AllocBlocks(disk *Disk, count int) ([]int32, error) {
+	blocks, err := sb.allocate(disk, sb.S_bm_block_start, sb.S_blocks_count, &sb.S_first_blo, &sb.S_free_blocks_count, count)
+	if err != nil {
+		return nil, fmt.Errorf("no hay bloques libres disponibles: %v", err)
+	}
+	return blocks, nil
+}
+
+// FreeInode libera un inodo
+func (sb *SuperBlock) FreeInode(disk *Disk, inodeNum int32) error {
+	return sb.release(disk, sb.S_bm_inode_start, sb.S_inodes_count, &sb.S_first_ino, &sb.S_free_inodes_count, []int32{inodeNum})
+}
+
+// FreeBlock libera un bloque
+func (sb *SuperBlock) FreeBlock(disk *Disk, blockNum int32) error {
+	return sb.FreeBlocks(disk, []int32{blockNum})
+}
+
+// FreeBlocks libera varios bloques con una sola lectura del bitmap
+func (sb *SuperBlock) FreeBlocks(disk *Disk, blocks []int32) error {
+	return sb.release(disk, sb.S_bm_block_start, sb.S_blocks_count, &sb.S_first_blo, &sb.S_free_blocks_count, blocks)
+}
+
+// allocate reserva count entradas del bitmap que empieza en bmStart y actualiza el cursor y el conteo libre
+func (sb *SuperBlock) allocate(disk *Disk, bmStart, total int32, cursor, free *int32, count int) ([]int32, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("cantidad inválida: %d", count)
+	}
+	if int(*free) < count {
+		return nil, fmt.Errorf("se necesitan %d y quedan %d", count, *free)
+	}
+
+	bm, err := sb.readBitmap(disk, bmStart, total)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer bitmap: %v", err)
+	}
+
+	start := *cursor
+	if start < 0 || start >= total {
+		start = 0
+	}
+
+	found := findRun(bm, start, count)
+	if found == nil {
+		found = findScattered(bm, start, count)
+	}
+	if found == nil {
+		return nil, fmt.Errorf("el bitmap no tiene %d entradas libres, pero el conteo libre es %d", count, *free)
+	}
+
+	low, high := found[0], found[0]
+	for _, idx := range found {
+		bm[idx] = '1'
+		low = min(low, idx)
+		high = max(high, idx)
+	}
+	err = sb.writeBitmap(disk, bmStart, low, bm[low:high+1])
+	if err != nil {
+		return nil, fmt.Errorf("error al escribir bitmap: %v", err)
+	}
+
+	*free -= int32(count)
+	*cursor = (found[len(found)-1] + 1) % total
+	return found, nil
+}
+
+// release marca como libres las entradas indicadas y actualiza el cursor y el conteo libre
+func (sb *SuperBlock) release(disk *Disk, bmStart, total int32, cursor, free *int32, entries []int32) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	bm, err := sb.readBitmap(disk, bmStart, total)
+	if err != nil {
+		return fmt.Errorf("error al leer bitmap: %v", err)
+	}
+
+	low, high := entries[0], entries[0]
+	for _, idx := range entries {
+		if idx < 0 || idx >= total {
+			return fmt.Errorf("índice fuera de rango: %d", idx)
+		}
+		if bm[idx] != '1' {
+			return fmt.Errorf("la entrada %d ya estaba libre", idx)
+		}
+		bm[idx] = '0'
+		low = min(low, idx)
+		high = max(high, idx)
+	}
+	err = sb.writeBitmap(disk, bmStart, low, bm[low:high+1])
+	if err != nil {
+		return fmt.Errorf("error al escribir bitmap: %v", err)
+	}
+
+	*free += int32(len(entries))
+	if low < *cursor || *cursor < 0 || *cursor >= total {
+		*cursor = low
+	}
+	return nil
+}
+
+// findRun busca count entradas libres contiguas empezando en start y dando la vuelta al bitmap
+func findRun(bm []byte, start int32, count int) []int32 {
+	total := int32(len(bm))
+	if count == 1 {
+		return findScattered(bm, start, 1)
+	}
+
+	// Un tramo no puede cruzar el final del bitmap, así que se revisa [start, total) y luego [0, start)
+	for _, r := range [][2]int32{{start, total}, {0, start}} {
+		runStart, runLen := int32(-1), 0
+		for i := r[0]; i < r[1]; i++ {
+			if bm[i] != '0' {
+				runLen = 0
+				continue
+			}
+			if runLen == 0 {
+				runStart = i
+			}
+			runLen++
+			if runLen == count {
+				run := make([]int32, count)
+				for j := range run {
+					run[j] = runStart + int32(j)
+				}
+				return run
+			}
+		}
+	}
+	return nil
+}
+
+// findScattered toma las primeras count entradas libres a partir de start, dando la vuelta al bitmap
+func findScattered(bm []byte, start int32, count int) []int32 {
+	total := int32(len(bm))
+	found := make([]int32, 0, count)
+	for n := int32(0); n < total && len(found) < count; n++ {
+		i := (start + n) % total
+		if bm[i] == '0' {
+			found = append(found, i)
+		}
+	}
+	if len(found) < count {
+		return nil
+	}
+	return found
+}
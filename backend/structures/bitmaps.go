@@ -2,17 +2,22 @@ package structures
 
 import (
 	"fmt"
-	"os"
 )
 
-// CreateBitMaps crea los Bitmaps de inodos y bloques en el archivo especificado
-func (sb *SuperBlock) CreateBitMaps(file *os.File) error {
-	// Bitmap de inodos
-	_, err := file.Seek(int64(sb.S_bm_inode_start), 0)
-	if err != nil {
-		return err
-	}
+/*
+Formato de los bitmaps.
+
+El formato original guarda cada entrada como un byte ASCII '0' o '1'. Con mkfs
+-bitmap=packed cada entrada ocupa un bit (el bit i%8 del byte i/8, empezando por el
+menos significativo), lo que se indica con FlagPackedBitmaps en S_filesystem_type.
+
+Fuera de este archivo los bitmaps siempre se manejan como un []byte de '0' y '1',
+sin importar cómo estén guardados en el disco.
+*/
 
+// CreateBitMaps crea los Bitmaps de inodos y bloques en el disco
+func (sb *SuperBlock) CreateBitMaps(disk *Disk) error {
+	// Bitmap de inodos
 	totalInodes := sb.S_inodes_count
 	buffer := make([]byte, totalInodes)
 	for i := range buffer {
@@ -24,17 +29,12 @@ func (sb *SuperBlock) CreateBitMaps(file *os.File) error {
 		buffer[1] = '1' // Inodo 1 ocupado (users.txt)
 	}
 
-	_, err = file.Write(buffer)
+	err := sb.writeBitmap(disk, sb.S_bm_inode_start, 0, buffer)
 	if err != nil {
 		return err
 	}
 
 	// Bitmap de bloques
-	_, err = file.Seek(int64(sb.S_bm_block_start), 0)
-	if err != nil {
-		return err
-	}
-
 	totalBlocks := sb.S_blocks_count
 	buffer = make([]byte, totalBlocks)
 	for i := range buffer {
@@ -45,60 +45,86 @@ func (sb *SuperBlock) CreateBitMaps(file *os.File) error {
 		buffer[1] = '1'
 	}
 
-	_, err = file.Write(buffer)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return sb.writeBitmap(disk, sb.S_bm_block_start, 0, buffer)
 }
 
-// UpdateBitmapInode actualiza un inodo específico en el bitmap
-func (sb *SuperBlock) UpdateBitmapInode(path string, inodeIndex int32) error {
-	file, err := os.OpenFile(path, os.O_RDWR, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
+// UpdateBitmapInode marca como ocupado un inodo específico en el bitmap
+func (sb *SuperBlock) UpdateBitmapInode(disk *Disk, inodeIndex int32) error {
 	if inodeIndex >= sb.S_inodes_count {
 		return fmt.Errorf("índice de inodo fuera de rango: %d", inodeIndex)
 	}
+	return sb.writeBitmap(disk, sb.S_bm_inode_start, inodeIndex, []byte{'1'})
+}
 
-	_, err = file.Seek(int64(sb.S_bm_inode_start)+int64(inodeIndex), 0)
-	if err != nil {
-		return err
+// UpdateBitmapBlock marca como ocupado un bloque específico en el bitmap
+func (sb *SuperBlock) UpdateBitmapBlock(disk *Disk, blockIndex int32) error {
+	if blockIndex >= sb.S_blocks_count {
+		return fmt.Errorf("índice de bloque fuera de rango: %d", blockIndex)
 	}
+	return sb.writeBitmap(disk, sb.S_bm_block_start, blockIndex, []byte{'1'})
+}
 
-	_, err = file.Write([]byte{'1'})
-	if err != nil {
-		return err
-	}
+// InodeBitmap devuelve el bitmap de inodos como un '0' o '1' por inodo
+func (sb *SuperBlock) InodeBitmap(disk *Disk) ([]byte, error) {
+	return sb.readBitmap(disk, sb.S_bm_inode_start, sb.S_inodes_count)
+}
 
-	return nil
+// BlockBitmap devuelve el bitmap de bloques como un '0' o '1' por bloque
+func (sb *SuperBlock) BlockBitmap(disk *Disk) ([]byte, error) {
+	return sb.readBitmap(disk, sb.S_bm_block_start, sb.S_blocks_count)
 }
 
-// UpdateBitmapBlock (similar ajuste)
-func (sb *SuperBlock) UpdateBitmapBlock(path string, blockIndex int32) error {
-	file, err := os.OpenFile(path, os.O_RDWR, 0644)
-	if err != nil {
-		return err
+// BitmapSize devuelve los bytes que ocupa en el disco un bitmap de count entradas
+func BitmapSize(count int32, packed bool) int32 {
+	if packed {
+		return (count + 7) / 8
 	}
-	defer file.Close()
+	return count
+}
 
-	if blockIndex >= sb.S_blocks_count {
-		return fmt.Errorf("índice de bloque fuera de rango: %d", blockIndex)
+// readBitmap lee count entradas de un bitmap a partir de la posición start
+func (sb *SuperBlock) readBitmap(disk *Disk, start int32, count int32) ([]byte, error) {
+	raw := make([]byte, BitmapSize(count, sb.PackedBitmaps()))
+	if err := disk.ReadAt(raw, int64(start)); err != nil {
+		return nil, err
+	}
+	if !sb.PackedBitmaps() {
+		return raw, nil
 	}
 
-	_, err = file.Seek(int64(sb.S_bm_block_start)+int64(blockIndex), 0)
-	if err != nil {
-		return err
+	bm := make([]byte, count)
+	for i := range bm {
+		bm[i] = '0'
+		if raw[i/8]&(1<<(i%8)) != 0 {
+			bm[i] = '1'
+		}
 	}
+	return bm, nil
+}
 
-	_, err = file.Write([]byte{'1'})
-	if err != nil {
-		return err
+// writeBitmap escribe las entradas data del bitmap a partir del índice from
+func (sb *SuperBlock) writeBitmap(disk *Disk, start int32, from int32, data []byte) error {
+	if !sb.PackedBitmaps() {
+		return disk.WriteAt(data, int64(start)+int64(from))
+	}
+	if len(data) == 0 {
+		return nil
 	}
 
-	return nil
+	// Los bytes de los extremos pueden tener entradas que no cambian: se leen antes de modificarlos
+	to := from + int32(len(data)) - 1
+	offset := int64(start) + int64(from/8)
+	raw := make([]byte, to/8-from/8+1)
+	if err := disk.ReadAt(raw, offset); err != nil {
+		return err
+	}
+	for i, entry := range data {
+		bit := from%8 + int32(i)
+		if entry == '1' {
+			raw[bit/8] |= 1 << (bit % 8)
+		} else {
+			raw[bit/8] &^= 1 << (bit % 8)
+		}
+	}
+	return disk.WriteAt(raw, offset)
 }
@@ -0,0 +1,209 @@
+package structures
+
+import (
+	"container/list"
+	"os"
+	"sync"
+)
+
+/*
+Caché de páginas.
+
+Cada imagen abierta tiene una caché LRU de páginas de PageSize bytes alineadas con el
+inicio del archivo. Los inodos, bloques de carpeta, bloques de archivo, bloques de
+apuntadores y los bitmaps se leen y escriben a través de ella, así que el inodo raíz y
+su bloque de carpeta quedan en memoria entre un comando y el siguiente.
+
+Las escrituras solo modifican la página en memoria y la marcan como sucia (write-back).
+Las páginas sucias se escriben en el disco:
+	- con el comando sync
+	- al cerrar sesión o desmontar la partición
+	- al cerrar el último Disk de la imagen
+	- cuando la caché se llena y la página es la menos usada recientemente
+	- cuando el servidor termina con SIGINT o SIGTERM (ver main.go)
+
+El MBR y los EBR se escriben además en el disco de inmediato (write-through, ver
+Disk.WriteStructThrough): una partición montada mantiene abierta la imagen, y sin esto
+una partición creada o montada se perdería si el proceso termina sin sincronizar.
+
+Las lecturas y escrituras de al menos largeAccess bytes (limpiar una partición, leer
+un bitmap completo) no pasan por la caché para no expulsar las páginas útiles.
+*/
+
+const (
+	PageSize    = 512  // Tamaño de una página de la caché
+	CachePages  = 2048 // Cantidad máxima de páginas por imagen
+	largeAccess = 64 * 1024
+)
+
+// page es una página de la caché
+type page struct {
+	offset int64  // Byte del archivo donde inicia la página
+	data   []byte // Contenido (más corto que PageSize al final del archivo)
+	dirty  bool   // Si fue modificada desde la última escritura en el disco
+}
+
+// pageCache es la caché LRU de páginas de un archivo
+type pageCache struct {
+	mu    sync.Mutex
+	file  *os.File
+	size  int64                   // Tamaño del archivo
+	pages map[int64]*list.Element // Páginas por offset
+	lru   *list.List              // Frente: la más usada recientemente
+}
+
+func newPageCache(file *os.File, size int64) *pageCache {
+	return &pageCache{
+		file:  file,
+		size:  size,
+		pages: make(map[int64]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// readAt llena buf con los bytes del archivo a partir de offset
+func (c *pageCache) readAt(buf []byte, offset int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(buf) >= largeAccess {
+		// Lo que esté pendiente en la caché debe llegar al disco antes de leer directamente
+		if err := c.flushRange(offset, int64(len(buf)), false); err != nil {
+			return err
+		}
+		_, err := c.file.ReadAt(buf, offset)
+		return err
+	}
+
+	for done := 0; done < len(buf); {
+		p, err := c.get(offset + int64(done))
+		if err != nil {
+			return err
+		}
+		done += copy(buf[done:], p.data[offset+int64(done)-p.offset:])
+	}
+	return nil
+}
+
+// writeAt escribe data a partir de offset
+func (c *pageCache) writeAt(data []byte, offset int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write(data, offset)
+}
+
+// writeThrough escribe data a partir de offset y escribe en el disco las páginas que modificó
+func (c *pageCache) writeThrough(data []byte, offset int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.write(data, offset); err != nil {
+		return err
+	}
+	return c.flushRange(offset, int64(len(data)), false)
+}
+
+// write escribe data a partir de offset; el llamador tiene c.mu
+func (c *pageCache) write(data []byte, offset int64) error {
+	if len(data) >= largeAccess {
+		// Las páginas cacheadas del rango quedarían desactualizadas: se escriben y se descartan
+		if err := c.flushRange(offset, int64(len(data)), true); err != nil {
+			return err
+		}
+		_, err := c.file.WriteAt(data, offset)
+		return err
+	}
+
+	for done := 0; done < len(data); {
+		p, err := c.get(offset + int64(done))
+		if err != nil {
+			return err
+		}
+		done += copy(p.data[offset+int64(done)-p.offset:], data[done:])
+		p.dirty = true
+	}
+	return nil
+}
+
+// get devuelve la página que contiene offset, leyéndola del disco si no está en la caché
+func (c *pageCache) get(offset int64) (*page, error) {
+	pageOffset := offset - offset%PageSize
+	if elem, ok := c.pages[pageOffset]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*page), nil
+	}
+
+	// Hacer espacio expulsando la página menos usada
+	if c.lru.Len() >= CachePages {
+		if err := c.evict(c.lru.Back()); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &page{offset: pageOffset, data: make([]byte, min(PageSize, c.size-pageOffset))}
+	if _, err := c.file.ReadAt(p.data, pageOffset); err != nil {
+		return nil, err
+	}
+	c.pages[pageOffset] = c.lru.PushFront(p)
+	return p, nil
+}
+
+// writeBack escribe la página en el disco si está sucia
+func (c *pageCache) writeBack(p *page) error {
+	if !p.dirty {
+		return nil
+	}
+	if _, err := c.file.WriteAt(p.data, p.offset); err != nil {
+		return err
+	}
+	p.dirty = false
+	return nil
+}
+
+// evict escribe la página si está sucia y la saca de la caché
+func (c *pageCache) evict(elem *list.Element) error {
+	p := elem.Value.(*page)
+	if err := c.writeBack(p); err != nil {
+		return err
+	}
+	c.lru.Remove(elem)
+	delete(c.pages, p.offset)
+	return nil
+}
+
+// flushRange escribe las páginas sucias que se solapan con [offset, offset+size) y,
+// si drop es true, las saca de la caché
+func (c *pageCache) flushRange(offset, size int64, drop bool) error {
+	for pageOffset := offset - offset%PageSize; pageOffset < offset+size; pageOffset += PageSize {
+		elem, ok := c.pages[pageOffset]
+		if !ok {
+			continue
+		}
+		if drop {
+			if err := c.evict(elem); err != nil {
+				return err
+			}
+		} else if err := c.writeBack(elem.Value.(*page)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush escribe todas las páginas sucias y devuelve cuántas fueron
+func (c *pageCache) flush() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	written := 0
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		p := elem.Value.(*page)
+		if !p.dirty {
+			continue
+		}
+		if err := c.writeBack(p); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
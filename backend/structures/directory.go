@@ -0,0 +1,484 @@
+package structures
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Name devuelve el nombre de la entrada sin los caracteres nulos
+func (fc *FolderContent) Name() string {
+	return strings.Trim(string(fc.B_name[:]), "\x00")
+}
+
+// IsFree indica si la entrada no apunta a ningún inodo
+func (fc *FolderContent) IsFree() bool {
+	return fc.B_inodo == -1 || fc.Name() == ""
+}
+
+// NewInode crea un inodo con todos los apuntadores libres y las fechas actuales
+func NewInode(inodeType byte, uid, gid int32, perm [3]byte) *Inode {
+	now := float32(time.Now().Unix())
+	inode := &Inode{
+		I_uid:   uid,
+		I_gid:   gid,
+		I_size:  0,
+		I_atime: now,
+		I_ctime: now,
+		I_mtime: now,
+		I_type:  [1]byte{inodeType},
+		I_perm:  perm,
+	}
+	for i := range inode.I_block {
+		inode.I_block[i] = -1
+	}
+	return inode
+}
+
+// ReadDirEntries devuelve las entradas ocupadas del directorio, incluyendo . y ..
+func (sb *SuperBlock) ReadDirEntries(disk *Disk, dir *Inode) ([]FolderContent, error) {
+	blocks, err := sb.GetInodeBlocks(disk, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []FolderContent
+	for _, blockNum := range blocks {
+		folderBlock := sb.NewFolderBlock()
+		err := folderBlock.Deserialize(disk, sb.BlockOffset(blockNum))
+		if err != nil {
+			return nil, fmt.Errorf("error al leer bloque %d: %v", blockNum, err)
+		}
+		for _, content := range folderBlock.B_content {
+			if !content.IsFree() {
+				entries = append(entries, content)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// FindEntry busca name dentro del directorio y devuelve su número de inodo, o -1 si no existe
+func (sb *SuperBlock) FindEntry(disk *Disk, dir *Inode, name string) (int32, error) {
+	entries, err := sb.ReadDirEntries(disk, dir)
+	if err != nil {
+		return -1, err
+	}
+	for _, content := range entries {
+		if content.Name() == name {
+			return content.B_inodo, nil
+		}
+	}
+	return -1, nil
+}
+
+// AddEntry enlaza el inodo child con el nombre name dentro del directorio dirNum.
+// Reutiliza la primera entrada libre o asigna un nuevo bloque (directo o indirecto) si no hay espacio.
+func (sb *SuperBlock) AddEntry(disk *Disk, dirNum int32, dir *Inode, name string, child int32) error {
+	blocks, err := sb.GetInodeBlocks(disk, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, blockNum := range blocks {
+		folderBlock := sb.NewFolderBlock()
+		err := folderBlock.Deserialize(disk, sb.BlockOffset(blockNum))
+		if err != nil {
+			return fmt.Errorf("error al leer bloque %d: %v", blockNum, err)
+		}
+		for i := range folderBlock.B_content {
+			if folderBlock.B_content[i].IsFree() {
+				folderBlock.B_content[i] = FolderContent{B_name: ToByte12(name), B_inodo: child}
+				return folderBlock.Serialize(disk, sb.BlockOffset(blockNum))
+			}
+		}
+	}
+
+	// No hay entradas libres: agregar un bloque nuevo al directorio
+	blockNum, err := sb.AllocateInodeBlock(disk, dir, len(blocks))
+	if err != nil {
+		return fmt.Errorf("no hay espacio en el directorio para crear %s: %v", name, err)
+	}
+	folderBlock := sb.NewFolderBlock()
+	folderBlock.B_content[0] = FolderContent{B_name: ToByte12(name), B_inodo: child}
+	err = folderBlock.Serialize(disk, sb.BlockOffset(blockNum))
+	if err != nil {
+		return fmt.Errorf("error al escribir bloque %d: %v", blockNum, err)
+	}
+
+	return dir.Serialize(disk, sb.InodeOffset(dirNum))
+}
+
+// RemoveEntry desvincula la entrada name del directorio y devuelve el inodo al que apuntaba
+func (sb *SuperBlock) RemoveEntry(disk *Disk, dir *Inode, name string) (int32, error) {
+	if name == "." || name == ".." {
+		return -1, fmt.Errorf("no se puede desvincular %s", name)
+	}
+
+	blocks, err := sb.GetInodeBlocks(disk, dir)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, blockNum := range blocks {
+		folderBlock := sb.NewFolderBlock()
+		err := folderBlock.Deserialize(disk, sb.BlockOffset(blockNum))
+		if err != nil {
+			return -1, fmt.Errorf("error al leer bloque %d: %v", blockNum, err)
+		}
+		for i := range folderBlock.B_content {
+			content := &folderBlock.B_content[i]
+			if content.IsFree() || content.Name() != name {
+				continue
+			}
+			removed := content.B_inodo
+			*content = FolderContent{B_name: ToByte12("-"), B_inodo: -1}
+			if err := folderBlock.Serialize(disk, sb.BlockOffset(blockNum)); err != nil {
+				return -1, fmt.Errorf("error al escribir bloque %d: %v", blockNum, err)
+			}
+			return removed, nil
+		}
+	}
+	return -1, fmt.Errorf("%s no encontrado", name)
+}
+
+// RenameEntry cambia el nombre de la entrada oldName del directorio por newName
+func (sb *SuperBlock) RenameEntry(disk *Disk, dir *Inode, oldName, newName string) error {
+	if oldName == "." || oldName == ".." {
+		return fmt.Errorf("no se puede renombrar %s", oldName)
+	}
+	existing, err := sb.FindEntry(disk, dir, newName)
+	if err != nil {
+		return err
+	}
+	if existing != -1 {
+		return fmt.Errorf("%s ya existe", newName)
+	}
+
+	return sb.updateEntry(disk, dir, oldName, func(content *FolderContent) {
+		content.B_name = ToByte12(newName)
+	})
+}
+
+// SetParentEntry actualiza la entrada .. de la carpeta dir para que apunte a parentNum
+func (sb *SuperBlock) SetParentEntry(disk *Disk, dir *Inode, parentNum int32) error {
+	return sb.updateEntry(disk, dir, "..", func(content *FolderContent) {
+		content.B_inodo = parentNum
+	})
+}
+
+// updateEntry aplica fn a la entrada name del directorio y escribe el bloque que la contiene
+func (sb *SuperBlock) updateEntry(disk *Disk, dir *Inode, name string, fn func(content *FolderContent)) error {
+	blocks, err := sb.GetInodeBlocks(disk, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, blockNum := range blocks {
+		folderBlock := sb.NewFolderBlock()
+		err := folderBlock.Deserialize(disk, sb.BlockOffset(blockNum))
+		if err != nil {
+			return fmt.Errorf("error al leer bloque %d: %v", blockNum, err)
+		}
+		for i := range folderBlock.B_content {
+			content := &folderBlock.B_content[i]
+			if content.IsFree() || content.Name() != name {
+				continue
+			}
+			fn(content)
+			return folderBlock.Serialize(disk, sb.BlockOffset(blockNum))
+		}
+	}
+	return fmt.Errorf("%s no encontrado", name)
+}
+
+// CloneInode copia el inodo srcNum y todo su contenido a inodos y bloques nuevos que pertenecen a uid y gid.
+// Las carpetas se copian recursivamente y su entrada .. apunta a parentNum.
+// skip permite omitir hijos (por ejemplo, los que el usuario no puede leer).
+// El inodo nuevo no queda enlazado; para eso se usa AddEntry.
+func (sb *SuperBlock) CloneInode(disk *Disk, srcNum int32, parentNum int32, uid, gid int32, skip func(inode *Inode) bool) (int32, error) {
+	src := &Inode{}
+	err := src.Deserialize(disk, sb.InodeOffset(srcNum))
+	if err != nil {
+		return -1, fmt.Errorf("error al leer inodo %d: %v", srcNum, err)
+	}
+
+	if src.I_type[0] != '0' {
+		content, err := sb.ReadFile(disk, src)
+		if err != nil {
+			return -1, err
+		}
+		newInodeNum, err := sb.AllocInode(disk)
+		if err != nil {
+			return -1, err
+		}
+		newInode := NewInode(src.I_type[0], uid, gid, src.I_perm)
+		err = sb.WriteFile(disk, newInodeNum, newInode, content)
+		if err != nil {
+			sb.discardInode(disk, newInodeNum, newInode)
+			return -1, err
+		}
+		return newInodeNum, nil
+	}
+
+	newInodeNum, newInode, err := sb.newFolder(disk, parentNum, uid, gid, src.I_perm)
+	if err != nil {
+		return -1, err
+	}
+	// Si la copia falla a medias, se libera la carpeta nueva con todo lo que ya se copió dentro
+	ok := false
+	defer func() {
+		if !ok {
+			sb.DeleteInode(disk, newInodeNum)
+		}
+	}()
+
+	entries, err := sb.ReadDirEntries(disk, src)
+	if err != nil {
+		return -1, err
+	}
+	for _, content := range entries {
+		name := content.Name()
+		if name == "." || name == ".." {
+			continue
+		}
+		child := &Inode{}
+		err := child.Deserialize(disk, sb.InodeOffset(content.B_inodo))
+		if err != nil {
+			return -1, fmt.Errorf("error al leer inodo %d: %v", content.B_inodo, err)
+		}
+		if skip != nil && skip(child) {
+			continue
+		}
+		childNum, err := sb.CloneInode(disk, content.B_inodo, newInodeNum, uid, gid, skip)
+		if err != nil {
+			return -1, err
+		}
+		err = sb.AddEntry(disk, newInodeNum, newInode, name, childNum)
+		if err != nil {
+			sb.DeleteInode(disk, childNum)
+			return -1, err
+		}
+	}
+	ok = true
+	return newInodeNum, nil
+}
+
+// IsAncestor indica si ancestorNum es la carpeta inodeNum o alguno de sus antecesores, siguiendo las entradas ..
+func (sb *SuperBlock) IsAncestor(disk *Disk, ancestorNum int32, inodeNum int32) (bool, error) {
+	current := inodeNum
+	for range sb.S_inodes_count {
+		if current == ancestorNum {
+			return true, nil
+		}
+		if current == 0 {
+			return false, nil
+		}
+		inode := &Inode{}
+		err := inode.Deserialize(disk, sb.InodeOffset(current))
+		if err != nil {
+			return false, fmt.Errorf("error al leer inodo %d: %v", current, err)
+		}
+		parentNum, err := sb.FindEntry(disk, inode, "..")
+		if err != nil {
+			return false, err
+		}
+		if parentNum == -1 {
+			return false, fmt.Errorf("el inodo %d no tiene entrada ..", current)
+		}
+		current = parentNum
+	}
+	return false, errors.New("ciclo detectado en las entradas ..")
+}
+
+// DeleteInode libera el inodo, sus bloques y, si es una carpeta, todo su contenido recursivamente.
+// No modifica la entrada del directorio padre; para eso se usa RemoveEntry.
+func (sb *SuperBlock) DeleteInode(disk *Disk, inodeNum int32) error {
+	if inodeNum == 0 {
+		return errors.New("no se puede eliminar la raíz")
+	}
+
+	inode := &Inode{}
+	err := inode.Deserialize(disk, sb.InodeOffset(inodeNum))
+	if err != nil {
+		return fmt.Errorf("error al leer inodo %d: %v", inodeNum, err)
+	}
+
+	if inode.I_type[0] == '0' {
+		entries, err := sb.ReadDirEntries(disk, inode)
+		if err != nil {
+			return err
+		}
+		for _, content := range entries {
+			name := content.Name()
+			if name == "." || name == ".." {
+				continue
+			}
+			if err := sb.DeleteInode(disk, content.B_inodo); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := sb.ReleaseInodeBlocks(disk, inode); err != nil {
+		return err
+	}
+	inode.I_size = 0
+	if err := inode.Serialize(disk, sb.InodeOffset(inodeNum)); err != nil {
+		return fmt.Errorf("error al escribir inodo %d: %v", inodeNum, err)
+	}
+	return sb.FreeInode(disk, inodeNum)
+}
+
+// WalkTree recorre en preorden el inodo inodeNum y, si es una carpeta, todo su contenido.
+// fn recibe el número de inodo, el inodo y su ruta formada a partir de itemPath.
+func (sb *SuperBlock) WalkTree(disk *Disk, inodeNum int32, itemPath string, fn func(inodeNum int32, inode *Inode, itemPath string) error) error {
+	inode := &Inode{}
+	err := inode.Deserialize(disk, sb.InodeOffset(inodeNum))
+	if err != nil {
+		return fmt.Errorf("error al leer inodo %d: %v", inodeNum, err)
+	}
+	if err := fn(inodeNum, inode, itemPath); err != nil {
+		return err
+	}
+	if inode.I_type[0] != '0' {
+		return nil
+	}
+
+	entries, err := sb.ReadDirEntries(disk, inode)
+	if err != nil {
+		return err
+	}
+	for _, content := range entries {
+		name := content.Name()
+		if name == "." || name == ".." {
+			continue
+		}
+		err := sb.WalkTree(disk, content.B_inodo, strings.TrimSuffix(itemPath, "/")+"/"+name, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindInodeByPath recorre los componentes de una ruta absoluta desde la raíz
+// y devuelve el número de inodo y el inodo del último componente
+func (sb *SuperBlock) FindInodeByPath(disk *Disk, components []string) (int32, *Inode, error) {
+	currentNum := int32(0) // Raíz
+	current := &Inode{}
+	err := current.Deserialize(disk, sb.InodeOffset(currentNum))
+	if err != nil {
+		return -1, nil, fmt.Errorf("error al leer inodo raíz: %v", err)
+	}
+
+	for _, name := range components {
+		if name == "" {
+			continue
+		}
+		if current.I_type[0] != '0' {
+			return -1, nil, fmt.Errorf("%s no está dentro de un directorio", name)
+		}
+		childNum, err := sb.FindEntry(disk, current, name)
+		if err != nil {
+			return -1, nil, err
+		}
+		if childNum == -1 {
+			return -1, nil, fmt.Errorf("%s no encontrado", name)
+		}
+		currentNum = childNum
+		current = &Inode{}
+		err = current.Deserialize(disk, sb.InodeOffset(currentNum))
+		if err != nil {
+			return -1, nil, fmt.Errorf("error al leer inodo %d: %v", currentNum, err)
+		}
+	}
+
+	return currentNum, current, nil
+}
+
+// MakeFolder crea una carpeta vacía (con . y ..) y la enlaza dentro del directorio parentNum
+func (sb *SuperBlock) MakeFolder(disk *Disk, parentNum int32, parent *Inode, name string, uid, gid int32, perm [3]byte) (int32, error) {
+	newInodeNum, newInode, err := sb.newFolder(disk, parentNum, uid, gid, perm)
+	if err != nil {
+		return -1, fmt.Errorf("error al crear %s: %v", name, err)
+	}
+
+	err = sb.AddEntry(disk, parentNum, parent, name, newInodeNum)
+	if err != nil {
+		sb.discardInode(disk, newInodeNum, newInode)
+		return -1, err
+	}
+	return newInodeNum, nil
+}
+
+// newFolder reserva un inodo de carpeta con su primer bloque (. y ..) sin enlazarlo a ningún directorio
+func (sb *SuperBlock) newFolder(disk *Disk, parentNum int32, uid, gid int32, perm [3]byte) (int32, *Inode, error) {
+	newInodeNum, err := sb.AllocInode(disk)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	newInode := NewInode('0', uid, gid, perm)
+	ok := false
+	defer func() {
+		if !ok {
+			sb.discardInode(disk, newInodeNum, newInode)
+		}
+	}()
+
+	newBlockNum, err := sb.AllocateInodeBlock(disk, newInode, 0)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	folderBlock := sb.NewFolderBlock()
+	folderBlock.B_content[0] = FolderContent{B_name: ToByte12("."), B_inodo: newInodeNum}
+	folderBlock.B_content[1] = FolderContent{B_name: ToByte12(".."), B_inodo: parentNum}
+	err = folderBlock.Serialize(disk, sb.BlockOffset(newBlockNum))
+	if err != nil {
+		return -1, nil, fmt.Errorf("error al serializar bloque %d: %v", newBlockNum, err)
+	}
+	err = newInode.Serialize(disk, sb.InodeOffset(newInodeNum))
+	if err != nil {
+		return -1, nil, fmt.Errorf("error al serializar inodo %d: %v", newInodeNum, err)
+	}
+	ok = true
+	return newInodeNum, newInode, nil
+}
+
+// MakeFile crea un archivo con el contenido indicado y lo enlaza dentro del directorio parentNum
+func (sb *SuperBlock) MakeFile(disk *Disk, parentNum int32, parent *Inode, name string, content []byte, uid, gid int32, perm [3]byte) (int32, error) {
+	newInodeNum, err := sb.AllocInode(disk)
+	if err != nil {
+		return -1, fmt.Errorf("error al encontrar inodo libre para %s: %v", name, err)
+	}
+
+	fileInode := NewInode('1', uid, gid, perm)
+	ok := false
+	defer func() {
+		if !ok {
+			sb.discardInode(disk, newInodeNum, fileInode)
+		}
+	}()
+
+	err = sb.WriteFile(disk, newInodeNum, fileInode, content)
+	if err != nil {
+		return -1, err
+	}
+
+	err = sb.AddEntry(disk, parentNum, parent, name, newInodeNum)
+	if err != nil {
+		return -1, err
+	}
+	ok = true
+	return newInodeNum, nil
+}
+
+// discardInode libera un inodo recién reservado que no quedó enlazado, junto con los bloques
+// que ya tenga asignados en memoria. Se usa para deshacer una creación que falló a medias.
+func (sb *SuperBlock) discardInode(disk *Disk, inodeNum int32, inode *Inode) {
+	sb.ReleaseInodeBlocks(disk, inode)
+	sb.FreeInode(disk, inodeNum)
+}
@@ -0,0 +1,201 @@
+package structures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+)
+
+/*
+Acceso a los discos.
+
+Cada imagen .mia se abre una sola vez: todos los Disk sobre el mismo archivo comparten
+el mismo *os.File y la misma caché de páginas (ver cache.go); el archivo se escribe y se
+cierra cuando se cierra el último. Un Disk cubre un rango de bytes (una partición o la
+imagen completa) y rechaza cualquier lectura o escritura que se salga de él.
+*/
+
+// Disk es una ventana de lectura y escritura sobre un rango de bytes de una imagen de disco
+type Disk struct {
+	Path  string // Ruta de la imagen
+	file  *sharedFile
+	start int64 // Primer byte del rango
+	end   int64 // Byte siguiente al último del rango
+}
+
+// sharedFile es un archivo abierto junto con su caché y la cantidad de Disk que lo usan
+type sharedFile struct {
+	file  *os.File
+	cache *pageCache
+	refs  int
+}
+
+var (
+	openFilesMu sync.Mutex
+	openFiles   = make(map[string]*sharedFile)
+)
+
+// OpenDisk abre el rango [start, start+size) de la imagen path
+func OpenDisk(path string, start, size int64) (*Disk, error) {
+	openFilesMu.Lock()
+	defer openFilesMu.Unlock()
+
+	shared, ok := openFiles[path]
+	if !ok {
+		file, err := os.OpenFile(path, os.O_RDWR, 0644)
+		if err != nil {
+			return nil, err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		shared = &sharedFile{file: file, cache: newPageCache(file, info.Size())}
+		openFiles[path] = shared
+	}
+	shared.refs++
+	return &Disk{Path: path, file: shared, start: start, end: start + size}, nil
+}
+
+// OpenImage abre la imagen path completa, para las estructuras que están fuera de las particiones
+func OpenImage(path string) (*Disk, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return OpenDisk(path, 0, info.Size())
+}
+
+// Close libera el Disk y, si nadie más usa el archivo, escribe las páginas pendientes y lo cierra
+func (d *Disk) Close() error {
+	openFilesMu.Lock()
+	defer openFilesMu.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+	shared := d.file
+	d.file = nil
+	shared.refs--
+	if shared.refs > 0 {
+		return nil
+	}
+	delete(openFiles, d.Path)
+	if _, err := shared.cache.flush(); err != nil {
+		shared.file.Close()
+		return err
+	}
+	return shared.file.Close()
+}
+
+// Sync escribe en el disco las páginas modificadas de la imagen y devuelve cuántas fueron
+func (d *Disk) Sync() (int, error) {
+	if d.file == nil {
+		return 0, fmt.Errorf("el disco %s está cerrado", d.Path)
+	}
+	return d.file.cache.flush()
+}
+
+// SyncAll escribe las páginas modificadas de todas las imágenes abiertas
+func SyncAll() (int, error) {
+	openFilesMu.Lock()
+	defer openFilesMu.Unlock()
+
+	total := 0
+	for path, shared := range openFiles {
+		written, err := shared.cache.flush()
+		total += written
+		if err != nil {
+			return total, fmt.Errorf("error al sincronizar %s: %v", path, err)
+		}
+	}
+	return total, nil
+}
+
+// Start devuelve el primer byte del rango
+func (d *Disk) Start() int64 {
+	return d.start
+}
+
+// Size devuelve la cantidad de bytes del rango
+func (d *Disk) Size() int64 {
+	return d.end - d.start
+}
+
+// checkRange verifica que [offset, offset+size) esté dentro del rango del Disk
+func (d *Disk) checkRange(offset int64, size int) error {
+	if d.file == nil {
+		return fmt.Errorf("el disco %s está cerrado", d.Path)
+	}
+	if offset < d.start || offset+int64(size) > d.end {
+		return fmt.Errorf("acceso fuera de la partición: bytes %d a %d, la partición va de %d a %d", offset, offset+int64(size), d.start, d.end)
+	}
+	return nil
+}
+
+// ReadAt llena buf con los bytes que empiezan en offset
+func (d *Disk) ReadAt(buf []byte, offset int64) error {
+	if err := d.checkRange(offset, len(buf)); err != nil {
+		return err
+	}
+	return d.file.cache.readAt(buf, offset)
+}
+
+// WriteAt escribe data a partir de offset
+func (d *Disk) WriteAt(data []byte, offset int64) error {
+	if err := d.checkRange(offset, len(data)); err != nil {
+		return err
+	}
+	return d.file.cache.writeAt(data, offset)
+}
+
+// ReadStruct deserializa v (little endian) desde offset
+func (d *Disk) ReadStruct(offset int64, v any) error {
+	size := binary.Size(v)
+	if size <= 0 {
+		return fmt.Errorf("tamaño inválido: %d", size)
+	}
+	buf := make([]byte, size)
+	if err := d.ReadAt(buf, offset); err != nil {
+		return err
+	}
+	return binary.Read(bytes.NewReader(buf), binary.LittleEndian, v)
+}
+
+// WriteStruct serializa v (little endian) en offset
+func (d *Disk) WriteStruct(offset int64, v any) error {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+		return err
+	}
+	return d.WriteAt(buf.Bytes(), offset)
+}
+
+// WriteStructThrough serializa v (little endian) en offset y lo escribe en el disco sin esperar a sync
+func (d *Disk) WriteStructThrough(offset int64, v any) error {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+		return err
+	}
+	if err := d.checkRange(offset, buf.Len()); err != nil {
+		return err
+	}
+	return d.file.cache.writeThrough(buf.Bytes(), offset)
+}
+
+// Zero llena con ceros size bytes a partir de offset, en tramos de 1 MB
+func (d *Disk) Zero(offset int64, size int64) error {
+	const chunkSize = 1024 * 1024
+	zeros := make([]byte, min(size, chunkSize))
+	for written := int64(0); written < size; {
+		n := min(size-written, chunkSize)
+		if err := d.WriteAt(zeros[:n], offset+written); err != nil {
+			return err
+		}
+		written += n
+	}
+	return nil
+}
@@ -1,10 +1,7 @@
 package structures
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
-	"os"
 )
 
 type EBR struct {
@@ -17,33 +14,14 @@ type EBR struct {
 	Part_id     [4]byte  // ID de la partición (nuevo campo)
 }
 
-// Serialize escribe el EBR en el archivo en la posición especificada
-func (ebr *EBR) Serialize(file *os.File, offset int64) error {
-	_, err := file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-	return binary.Write(file, binary.LittleEndian, ebr)
+// Serialize escribe el EBR en el disco en la posición especificada
+func (ebr *EBR) Serialize(disk *Disk, offset int64) error {
+	return disk.WriteStructThrough(offset, ebr)
 }
 
-// Deserialize lee un EBR desde el archivo en la posición especificada
-// Deserialize lee la estructura EBR desde un archivo binario en la posición especificada
-func (ebr *EBR) Deserialize(file *os.File, offset int64) error {
-	_, err := file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-	ebrSize := binary.Size(ebr)
-	if ebrSize <= 0 {
-		return fmt.Errorf("invalid EBR size: %d", ebrSize)
-	}
-	buffer := make([]byte, ebrSize)
-	_, err = file.Read(buffer)
-	if err != nil {
-		return err
-	}
-	reader := bytes.NewReader(buffer)
-	return binary.Read(reader, binary.LittleEndian, ebr)
+// Deserialize lee la estructura EBR desde el disco en la posición especificada
+func (ebr *EBR) Deserialize(disk *Disk, offset int64) error {
+	return disk.ReadStruct(offset, ebr)
 }
 
 // PrintEBR imprime los valores del EBR para depuración
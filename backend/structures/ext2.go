@@ -5,8 +5,8 @@ import (
 	"time"
 )
 
-// Crear users.txt en nuestro sistema de archivos
-func (sb *SuperBlock) CreateUsersFile(path string) error {
+// Crear users.txt en nuestro sistema de archivos con el contenido usersText
+func (sb *SuperBlock) CreateUsersFile(disk *Disk, usersText string) error {
 	// ----------- Creamos / -----------
 	rootInode := &Inode{
 		I_uid:   1,
@@ -19,34 +19,29 @@ func (sb *SuperBlock) CreateUsersFile(path string) error {
 		I_type:  [1]byte{'0'},
 		I_perm:  [3]byte{'7', '7', '7'},
 	}
-	err := rootInode.Serialize(path, int64(sb.S_inode_start)) // Inodo 0
+	err := rootInode.Serialize(disk, int64(sb.S_inode_start)) // Inodo 0
 	if err != nil {
 		return fmt.Errorf("error al serializar inodo raíz: %v", err)
 	}
-	err = sb.UpdateBitmapInode(path, 0)
+	err = sb.UpdateBitmapInode(disk, 0)
 	if err != nil {
 		return err
 	}
 
-	rootBlock := &FolderBlock{
-		B_content: [4]FolderContent{
-			{B_name: ToByte12("."), B_inodo: 0},
-			{B_name: ToByte12(".."), B_inodo: 0},
-			{B_name: ToByte12("users.txt"), B_inodo: 1},
-			{B_name: ToByte12("-"), B_inodo: -1},
-		},
-	}
-	err = rootBlock.Serialize(path, int64(sb.S_block_start)) // Bloque 0
+	rootBlock := sb.NewFolderBlock()
+	rootBlock.B_content[0] = FolderContent{B_name: ToByte12("."), B_inodo: 0}
+	rootBlock.B_content[1] = FolderContent{B_name: ToByte12(".."), B_inodo: 0}
+	rootBlock.B_content[2] = FolderContent{B_name: ToByte12("users.txt"), B_inodo: 1}
+	err = rootBlock.Serialize(disk, int64(sb.S_block_start)) // Bloque 0
 	if err != nil {
 		return fmt.Errorf("error al serializar bloque raíz: %v", err)
 	}
-	err = sb.UpdateBitmapBlock(path, 0)
+	err = sb.UpdateBitmapBlock(disk, 0)
 	if err != nil {
 		return err
 	}
 
 	// ----------- Creamos /users.txt -----------
-	usersText := "1,G,root\n1,U,root,123\n"
 	usersInode := &Inode{
 		I_uid:   1,
 		I_gid:   1,
@@ -56,31 +51,36 @@ func (sb *SuperBlock) CreateUsersFile(path string) error {
 		I_mtime: float32(time.Now().Unix()),
 		I_block: [15]int32{1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, // Bloque 1
 		I_type:  [1]byte{'1'},
-		I_perm:  [3]byte{'7', '7', '7'},
+		I_perm:  [3]byte{'6', '6', '0'}, // Solo root y el grupo root
 	}
-	err = usersInode.Serialize(path, int64(sb.S_inode_start+sb.S_inode_size)) // Inodo 1
+	err = usersInode.Serialize(disk, int64(sb.S_inode_start+sb.S_inode_size)) // Inodo 1
 	if err != nil {
 		return fmt.Errorf("error al serializar inodo users.txt: %v", err)
 	}
-	err = sb.UpdateBitmapInode(path, 1)
+	err = sb.UpdateBitmapInode(disk, 1)
 	if err != nil {
 		return err
 	}
 
-	usersBlock := &FileBlock{B_content: [64]byte{}}
-	copy(usersBlock.B_content[:], usersText)
-	err = usersBlock.Serialize(path, int64(sb.S_block_start+sb.S_block_size)) // Bloque 1
-	if err != nil {
-		return fmt.Errorf("error al serializar bloque users.txt: %v", err)
-	}
-	err = sb.UpdateBitmapBlock(path, 1)
+	err = sb.UpdateBitmapBlock(disk, 1)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("DEBUG: users.txt escrito en bloque 1 con contenido: %s\n", usersText)
 	sb.S_first_ino = 2 // Próximo inodo libre
 	sb.S_first_blo = 2 // Próximo bloque libre
 
+	// El contenido puede necesitar más bloques que el bloque 1
+	return sb.WriteFile(disk, 1, usersInode, []byte(usersText))
+}
+
+// ClearFileSystem llena de ceros los bitmaps, la tabla de inodos y el área de bloques.
+// El superbloque y el journal no se modifican.
+func (sb *SuperBlock) ClearFileSystem(disk *Disk) error {
+	start := int64(sb.S_bm_inode_start)
+	end := sb.BlockOffset(sb.S_blocks_count)
+	if err := disk.Zero(start, end-start); err != nil {
+		return fmt.Errorf("error al limpiar el sistema de archivos: %v", err)
+	}
 	return nil
 }
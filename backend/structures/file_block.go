@@ -1,75 +1,31 @@
 package structures
 
 import (
-	"bytes"
-	"encoding/binary"
+	"errors"
 	"fmt"
-	"os"
 )
 
 type FileBlock struct {
-	B_content [64]byte
-	// Total: 64 bytes
+	B_content []byte // S_block_size bytes
 }
 
-// Serialize escribe la estructura FileBlock en un archivo binario en la posición especificada
-func (fb *FileBlock) Serialize(path string, offset int64) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Serializar la estructura FileBlock directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, fb)
-	if err != nil {
-		return err
-	}
-
-	return nil
+// NewFileBlock crea un bloque de archivo vacío del tamaño de bloque del sistema de archivos
+func (sb *SuperBlock) NewFileBlock() *FileBlock {
+	return &FileBlock{B_content: make([]byte, sb.S_block_size)}
 }
 
-// Deserialize lee la estructura FileBlock desde un archivo binario en la posición especificada
-func (fb *FileBlock) Deserialize(path string, offset int64) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Obtener el tamaño de la estructura FileBlock
-	fbSize := binary.Size(fb)
-	if fbSize <= 0 {
-		return fmt.Errorf("invalid FileBlock size: %d", fbSize)
-	}
-
-	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura FileBlock
-	buffer := make([]byte, fbSize)
-	_, err = file.Read(buffer)
-	if err != nil {
-		return err
-	}
+// Serialize escribe la estructura FileBlock en el disco en la posición especificada
+func (fb *FileBlock) Serialize(disk *Disk, offset int64) error {
+	return disk.WriteAt(fb.B_content, offset)
+}
 
-	// Deserializar los bytes leídos en la estructura FileBlock
-	reader := bytes.NewReader(buffer)
-	err = binary.Read(reader, binary.LittleEndian, fb)
-	if err != nil {
-		return err
+// Deserialize lee la estructura FileBlock desde el disco en la posición especificada.
+// El bloque debe crearse con NewFileBlock para saber cuántos bytes leer.
+func (fb *FileBlock) Deserialize(disk *Disk, offset int64) error {
+	if len(fb.B_content) == 0 {
+		return errors.New("bloque de archivo sin tamaño, use NewFileBlock")
 	}
-
-	return nil
+	return disk.ReadAt(fb.B_content, offset)
 }
 
 // PrintContent prints the content of B_content as a string
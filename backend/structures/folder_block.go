@@ -1,15 +1,13 @@
 package structures
 
 import (
-	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
-	"os"
 )
 
 type FolderBlock struct {
-	B_content [4]FolderContent // 4 * 16 = 64 bytes
-	// Total: 64 bytes
+	B_content []FolderContent // S_block_size / 16 entradas
 }
 
 type FolderContent struct {
@@ -18,64 +16,32 @@ type FolderContent struct {
 	// Total: 16 bytes
 }
 
-// Serialize escribe la estructura FolderBlock en un archivo binario en la posición especificada
-func (fb *FolderBlock) Serialize(path string, offset int64) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Serializar la estructura FolderBlock directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, fb)
-	if err != nil {
-		return err
-	}
-
-	return nil
+// FolderEntries devuelve cuántas entradas caben en un bloque de carpeta
+func (sb *SuperBlock) FolderEntries() int {
+	return int(sb.S_block_size) / binary.Size(FolderContent{})
 }
 
-// Deserialize lee la estructura FolderBlock desde un archivo binario en la posición especificada
-func (fb *FolderBlock) Deserialize(path string, offset int64) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Obtener el tamaño de la estructura FolderBlock
-	fbSize := binary.Size(fb)
-	if fbSize <= 0 {
-		return fmt.Errorf("invalid FolderBlock size: %d", fbSize)
+// NewFolderBlock crea un bloque de carpeta con todas sus entradas libres
+func (sb *SuperBlock) NewFolderBlock() *FolderBlock {
+	fb := &FolderBlock{B_content: make([]FolderContent, sb.FolderEntries())}
+	for i := range fb.B_content {
+		fb.B_content[i] = FolderContent{B_name: ToByte12("-"), B_inodo: -1}
 	}
+	return fb
+}
 
-	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura FolderBlock
-	buffer := make([]byte, fbSize)
-	_, err = file.Read(buffer)
-	if err != nil {
-		return err
-	}
+// Serialize escribe la estructura FolderBlock en el disco en la posición especificada
+func (fb *FolderBlock) Serialize(disk *Disk, offset int64) error {
+	return disk.WriteStruct(offset, fb.B_content)
+}
 
-	// Deserializar los bytes leídos en la estructura FolderBlock
-	reader := bytes.NewReader(buffer)
-	err = binary.Read(reader, binary.LittleEndian, fb)
-	if err != nil {
-		return err
+// Deserialize lee la estructura FolderBlock desde el disco en la posición especificada.
+// El bloque debe crearse con NewFolderBlock para saber cuántas entradas leer.
+func (fb *FolderBlock) Deserialize(disk *Disk, offset int64) error {
+	if len(fb.B_content) == 0 {
+		return errors.New("bloque de carpeta sin tamaño, use NewFolderBlock")
 	}
-
-	return nil
+	return disk.ReadStruct(offset, fb.B_content)
 }
 
 // Print imprime los atributos del bloque de carpeta
@@ -0,0 +1,90 @@
+package structures
+
+import (
+	"sort"
+)
+
+/*
+Mapa de espacio libre.
+
+Un disco (o una partición extendida) se ve como una lista de segmentos ocupados.
+Los huecos entre ellos son el espacio libre, y el ajuste decide en cuál hueco
+se coloca una partición nueva:
+	B: el hueco más pequeño en el que quepa (best fit)
+	F: el primer hueco en el que quepa (first fit)
+	W: el hueco más grande (worst fit)
+*/
+
+// Segment es un rango contiguo de bytes del disco
+type Segment struct {
+	Start int32 // Byte de inicio
+	Size  int32 // Tamaño en bytes
+}
+
+// End devuelve el byte siguiente al último del segmento
+func (s Segment) End() int32 {
+	return s.Start + s.Size
+}
+
+// FreeSegments devuelve, ordenados por inicio, los huecos de [start, end) que no cubre ningún segmento de used
+func FreeSegments(start, end int32, used []Segment) []Segment {
+	sorted := make([]Segment, len(used))
+	copy(sorted, used)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var free []Segment
+	cursor := start
+	for _, seg := range sorted {
+		if seg.Size <= 0 {
+			continue
+		}
+		if seg.Start > cursor {
+			free = append(free, Segment{Start: cursor, Size: min(seg.Start, end) - cursor})
+		}
+		cursor = max(cursor, seg.End())
+		if cursor >= end {
+			return free
+		}
+	}
+	if cursor < end {
+		free = append(free, Segment{Start: cursor, Size: end - cursor})
+	}
+	return free
+}
+
+// SelectFit elige entre los huecos libres uno de al menos size bytes según el ajuste (B, F o W).
+// Devuelve false si ninguno alcanza.
+func SelectFit(free []Segment, size int32, fit byte) (Segment, bool) {
+	best := -1
+	for i, seg := range free {
+		if seg.Size < size {
+			continue
+		}
+		switch fit {
+		case 'B':
+			if best == -1 || seg.Size < free[best].Size {
+				best = i
+			}
+		case 'W':
+			if best == -1 || seg.Size > free[best].Size {
+				best = i
+			}
+		default:
+			return seg, true
+		}
+	}
+	if best == -1 {
+		return Segment{}, false
+	}
+	return free[best], true
+}
+
+// FollowingFree devuelve cuántos bytes libres hay inmediatamente después de end
+func FollowingFree(free []Segment, end int32) int32 {
+	for _, seg := range free {
+		if seg.Start == end {
+			return seg.Size
+		}
+	}
+	return 0
+}
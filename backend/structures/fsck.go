@@ -0,0 +1,338 @@
+package structures
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+Verificación del sistema de archivos.
+
+Se recorre el árbol desde la raíz para saber qué inodos y bloques están realmente en uso,
+y eso se compara con los bitmaps y con los contadores del superbloque. En cada carpeta se
+validan . y .., en cada archivo que I_size corresponda a los bloques que tiene, y en todos
+los inodos que ningún bloque esté reclamado dos veces.
+
+Al reparar, los bitmaps y los contadores se reconstruyen a partir de lo alcanzable desde la
+raíz, de modo que los inodos huérfanos y los bloques sin dueño quedan libres. El llamador
+debe serializar el superbloque.
+*/
+
+// FsckIssue es un problema encontrado por Check
+type FsckIssue struct {
+	Description string // Descripción del problema
+	Repaired    bool   // Si se reparó
+}
+
+// FsckResult es el resultado de verificar una partición
+type FsckResult struct {
+	Issues      []FsckIssue
+	UsedInodes  int32 // Inodos alcanzables desde la raíz
+	UsedBlocks  int32 // Bloques alcanzables desde la raíz
+	Directories int32
+	Files       int32
+}
+
+// Unrepaired devuelve cuántos problemas quedaron sin reparar
+func (r *FsckResult) Unrepaired() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if !issue.Repaired {
+			count++
+		}
+	}
+	return count
+}
+
+// fsckMaxListed es la cantidad máxima de números de inodo o bloque que se listan por problema
+const fsckMaxListed = 10
+
+// fsck guarda el estado de una verificación en curso
+type fsck struct {
+	sb         *SuperBlock
+	disk       *Disk
+	repair     bool
+	reachable  []bool  // Inodos alcanzables desde la raíz
+	blockOwner []int32 // Inodo dueño de cada bloque, o -1
+	result     *FsckResult
+}
+
+// Check verifica el sistema de archivos de la partición y, si repair es true, corrige lo que pueda
+func (sb *SuperBlock) Check(disk *Disk, repair bool) (*FsckResult, error) {
+	c := &fsck{
+		sb:         sb,
+		disk:       disk,
+		repair:     repair,
+		reachable:  make([]bool, sb.S_inodes_count),
+		blockOwner: make([]int32, sb.S_blocks_count),
+		result:     &FsckResult{},
+	}
+	for i := range c.blockOwner {
+		c.blockOwner[i] = -1
+	}
+
+	root := &Inode{}
+	if err := root.Deserialize(disk, sb.InodeOffset(0)); err != nil {
+		return nil, fmt.Errorf("error al leer inodo raíz: %v", err)
+	}
+	if root.I_type[0] != '0' {
+		return nil, errors.New("el inodo 0 no es una carpeta, la raíz está dañada")
+	}
+	c.reachable[0] = true
+	if err := c.checkInode(0, root, 0, "/"); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkBitmap("inodos", sb.S_bm_inode_start, c.reachable, &sb.S_free_inodes_count, &sb.S_first_ino); err != nil {
+		return nil, err
+	}
+	usedBlocks := make([]bool, len(c.blockOwner))
+	for i, owner := range c.blockOwner {
+		usedBlocks[i] = owner != -1
+	}
+	if err := c.checkBitmap("bloques", sb.S_bm_block_start, usedBlocks, &sb.S_free_blocks_count, &sb.S_first_blo); err != nil {
+		return nil, err
+	}
+
+	return c.result, nil
+}
+
+// report agrega un problema al resultado
+func (c *fsck) report(repaired bool, format string, args ...any) {
+	c.result.Issues = append(c.result.Issues, FsckIssue{Description: fmt.Sprintf(format, args...), Repaired: repaired})
+}
+
+// checkInode verifica el inodo inodeNum, que se alcanzó desde la carpeta parentNum, y su contenido
+func (c *fsck) checkInode(inodeNum int32, inode *Inode, parentNum int32, itemPath string) error {
+	c.result.UsedInodes++
+
+	// Reclamar los bloques del inodo; un apuntador fuera de rango corta el recorrido de ese inodo
+	dataBlocks := 0
+	err := c.sb.WalkInodeBlocks(c.disk, inode, func(blockNum int32, level int) error {
+		if blockNum < 0 || blockNum >= c.sb.S_blocks_count {
+			return fmt.Errorf("apunta al bloque %d, fuera de rango", blockNum)
+		}
+		if owner := c.blockOwner[blockNum]; owner != -1 {
+			c.report(false, "%s (inodo %d): el bloque %d ya pertenece al inodo %d", itemPath, inodeNum, blockNum, owner)
+			return nil
+		}
+		c.blockOwner[blockNum] = inodeNum
+		c.result.UsedBlocks++
+		if level == 0 {
+			dataBlocks++
+		}
+		return nil
+	})
+	if err != nil {
+		c.report(false, "%s (inodo %d): %v", itemPath, inodeNum, err)
+		return nil
+	}
+
+	if inode.I_type[0] == '1' {
+		c.result.Files++
+		return c.checkFileSize(inodeNum, inode, dataBlocks, itemPath)
+	}
+	c.result.Directories++
+	return c.checkDirectory(inodeNum, inode, parentNum, itemPath)
+}
+
+// checkFileSize verifica que I_size corresponda a la cantidad de bloques de datos del archivo
+func (c *fsck) checkFileSize(inodeNum int32, inode *Inode, dataBlocks int, itemPath string) error {
+	blockSize := c.sb.S_block_size
+	capacity := int32(dataBlocks) * blockSize
+	if inode.I_size < 0 || inode.I_size > capacity {
+		c.report(c.repair, "%s (inodo %d): I_size es %d pero sus %d bloques solo tienen %d bytes", itemPath, inodeNum, inode.I_size, dataBlocks, capacity)
+		if c.repair {
+			inode.I_size = max(min(inode.I_size, capacity), 0)
+			if err := inode.Serialize(c.disk, c.sb.InodeOffset(inodeNum)); err != nil {
+				return fmt.Errorf("error al escribir inodo %d: %v", inodeNum, err)
+			}
+		}
+		return nil
+	}
+
+	// Un archivo vacío conserva un bloque
+	needed := max(int((inode.I_size+blockSize-1)/blockSize), 1)
+	if dataBlocks != needed {
+		c.report(false, "%s (inodo %d): I_size es %d, que ocupa %d bloques, pero tiene %d", itemPath, inodeNum, inode.I_size, needed, dataBlocks)
+	}
+	return nil
+}
+
+// checkDirectory valida . y .. de la carpeta y verifica recursivamente sus entradas
+func (c *fsck) checkDirectory(dirNum int32, dir *Inode, parentNum int32, itemPath string) error {
+	entries, err := c.sb.ReadDirEntries(c.disk, dir)
+	if err != nil {
+		c.report(false, "%s (inodo %d): %v", itemPath, dirNum, err)
+		return nil
+	}
+
+	if err := c.checkDotEntry(dirNum, dir, entries, ".", dirNum, itemPath); err != nil {
+		return err
+	}
+	if err := c.checkDotEntry(dirNum, dir, entries, "..", parentNum, itemPath); err != nil {
+		return err
+	}
+
+	for _, content := range entries {
+		name := content.Name()
+		if name == "." || name == ".." {
+			continue
+		}
+		childPath := strings.TrimSuffix(itemPath, "/") + "/" + name
+		childNum := content.B_inodo
+
+		problem := ""
+		child := &Inode{}
+		switch {
+		case childNum < 0 || childNum >= c.sb.S_inodes_count:
+			problem = fmt.Sprintf("apunta al inodo %d, fuera de rango", childNum)
+		case c.reachable[childNum]:
+			problem = fmt.Sprintf("el inodo %d ya está enlazado desde otra entrada", childNum)
+		default:
+			if err := child.Deserialize(c.disk, c.sb.InodeOffset(childNum)); err != nil {
+				return fmt.Errorf("error al leer inodo %d: %v", childNum, err)
+			}
+			if child.I_type[0] != '0' && child.I_type[0] != '1' {
+				problem = fmt.Sprintf("el inodo %d no es una carpeta ni un archivo", childNum)
+			}
+		}
+		if problem != "" {
+			c.report(c.repair, "%s: %s", childPath, problem)
+			if c.repair {
+				if _, err := c.sb.RemoveEntry(c.disk, dir, name); err != nil {
+					return fmt.Errorf("error al desvincular %s: %v", childPath, err)
+				}
+			}
+			continue
+		}
+
+		c.reachable[childNum] = true
+		if err := c.checkInode(childNum, child, dirNum, childPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDotEntry verifica que la entrada name (. o ..) exista una sola vez y apunte a target
+func (c *fsck) checkDotEntry(dirNum int32, dir *Inode, entries []FolderContent, name string, target int32, itemPath string) error {
+	found := 0
+	correct := false
+	for _, content := range entries {
+		if content.Name() == name {
+			found++
+			correct = content.B_inodo == target
+		}
+	}
+	if found == 1 && correct {
+		return nil
+	}
+
+	switch {
+	case found == 0:
+		c.report(c.repair, "%s (inodo %d): falta la entrada %s", itemPath, dirNum, name)
+	case found > 1:
+		c.report(false, "%s (inodo %d): la entrada %s aparece %d veces", itemPath, dirNum, name, found)
+		return nil
+	default:
+		c.report(c.repair, "%s (inodo %d): la entrada %s no apunta al inodo %d", itemPath, dirNum, name, target)
+	}
+	if !c.repair {
+		return nil
+	}
+
+	if found == 1 {
+		return c.sb.updateEntry(c.disk, dir, name, func(content *FolderContent) {
+			content.B_inodo = target
+		})
+	}
+	// Solo se usa una entrada libre de los bloques existentes, para no reservar bloques durante la verificación
+	blocks, err := c.sb.GetInodeBlocks(c.disk, dir)
+	if err != nil {
+		return err
+	}
+	for _, blockNum := range blocks {
+		folderBlock := c.sb.NewFolderBlock()
+		if err := folderBlock.Deserialize(c.disk, c.sb.BlockOffset(blockNum)); err != nil {
+			return fmt.Errorf("error al leer bloque %d: %v", blockNum, err)
+		}
+		for i := range folderBlock.B_content {
+			if folderBlock.B_content[i].IsFree() {
+				folderBlock.B_content[i] = FolderContent{B_name: ToByte12(name), B_inodo: target}
+				return folderBlock.Serialize(c.disk, c.sb.BlockOffset(blockNum))
+			}
+		}
+	}
+	c.result.Issues[len(c.result.Issues)-1].Repaired = false
+	return nil
+}
+
+// checkBitmap compara el bitmap que empieza en bmStart con las entradas en uso y verifica el contador libre.
+// Al reparar, el bitmap se reescribe completo y el contador y el cursor se ajustan.
+func (c *fsck) checkBitmap(kind string, bmStart int32, used []bool, free *int32, cursor *int32) error {
+	total := int32(len(used))
+	bm, err := c.sb.readBitmap(c.disk, bmStart, total)
+	if err != nil {
+		return fmt.Errorf("error al leer bitmap de %s: %v", kind, err)
+	}
+
+	var markedFree, unowned []int32
+	expected := make([]byte, total)
+	usedCount := int32(0)
+	for i := range used {
+		expected[i] = '0'
+		if used[i] {
+			expected[i] = '1'
+			usedCount++
+		}
+		switch {
+		case used[i] && bm[i] != '1':
+			markedFree = append(markedFree, int32(i))
+		case !used[i] && bm[i] == '1':
+			unowned = append(unowned, int32(i))
+		}
+	}
+
+	if len(markedFree) > 0 {
+		c.report(c.repair, "%s en uso marcados como libres en el bitmap: %s", kind, listEntries(markedFree))
+	}
+	if len(unowned) > 0 {
+		orphan := "huérfanos"
+		if kind == "bloques" {
+			orphan = "sin dueño"
+		}
+		c.report(c.repair, "%s %s (marcados en el bitmap pero no alcanzables desde la raíz): %s", kind, orphan, listEntries(unowned))
+	}
+	if c.repair && (len(markedFree) > 0 || len(unowned) > 0) {
+		if err := c.sb.writeBitmap(c.disk, bmStart, 0, expected); err != nil {
+			return fmt.Errorf("error al escribir bitmap de %s: %v", kind, err)
+		}
+	}
+
+	if *free != total-usedCount {
+		c.report(c.repair, "el superbloque indica %d %s libres, pero hay %d", *free, kind, total-usedCount)
+		if c.repair {
+			*free = total - usedCount
+		}
+	}
+	if c.repair && (*cursor < 0 || *cursor >= total) {
+		*cursor = 0
+	}
+	return nil
+}
+
+// listEntries da formato a una lista de números de inodo o bloque, mostrando solo los primeros
+func listEntries(entries []int32) string {
+	shown := entries[:min(len(entries), fsckMaxListed)]
+	parts := make([]string, len(shown))
+	for i, n := range shown {
+		parts[i] = fmt.Sprint(n)
+	}
+	text := strings.Join(parts, ", ")
+	if len(entries) > len(shown) {
+		text += fmt.Sprintf(" y %d más", len(entries)-len(shown))
+	}
+	return text
+}
@@ -1,10 +1,7 @@
 package structures
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
-	"os"
 	"time"
 )
 
@@ -21,64 +18,14 @@ type Inode struct {
 	// Total: 88 bytes
 }
 
-// Serialize escribe la estructura Inode en un archivo binario en la posición especificada
-func (inode *Inode) Serialize(path string, offset int64) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Serializar la estructura Inode directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, inode)
-	if err != nil {
-		return err
-	}
-
-	return nil
+// Serialize escribe la estructura Inode en el disco en la posición especificada
+func (inode *Inode) Serialize(disk *Disk, offset int64) error {
+	return disk.WriteStruct(offset, inode)
 }
 
-// Deserialize lee la estructura Inode desde un archivo binario en la posición especificada
-func (inode *Inode) Deserialize(path string, offset int64) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Obtener el tamaño de la estructura Inode
-	inodeSize := binary.Size(inode)
-	if inodeSize <= 0 {
-		return fmt.Errorf("invalid Inode size: %d", inodeSize)
-	}
-
-	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura Inode
-	buffer := make([]byte, inodeSize)
-	_, err = file.Read(buffer)
-	if err != nil {
-		return err
-	}
-
-	// Deserializar los bytes leídos en la estructura Inode
-	reader := bytes.NewReader(buffer)
-	err = binary.Read(reader, binary.LittleEndian, inode)
-	if err != nil {
-		return err
-	}
-
-	return nil
+// Deserialize lee la estructura Inode desde el disco en la posición especificada
+func (inode *Inode) Deserialize(disk *Disk, offset int64) error {
+	return disk.ReadStruct(offset, inode)
 }
 
 // Print imprime los atributos del inodo
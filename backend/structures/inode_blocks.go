@@ -0,0 +1,386 @@
+package structures
+
+import (
+	"fmt"
+	"time"
+)
+
+// DirectBlocks es la cantidad de apuntadores directos en I_block (I_block[0..11])
+const DirectBlocks = 12
+
+// MaxInodeBlocks devuelve la cantidad máxima de bloques de datos que puede direccionar un inodo
+func (sb *SuperBlock) MaxInodeBlocks() int {
+	ptrs := sb.PointersPerBlock()
+	return DirectBlocks + ptrs + ptrs*ptrs + ptrs*ptrs*ptrs
+}
+
+/*
+I_block:
+	0..11: bloques directos
+	12:    bloque de apuntadores simple
+	13:    bloque de apuntadores doble
+	14:    bloque de apuntadores triple
+
+Las versiones anteriores creaban inodos con I_block en 0 en lugar de -1. El bloque 0
+siempre es el primer bloque de la raíz, así que un 0 fuera de I_block[0] se trata como libre.
+*/
+
+// WalkInodeBlocks recorre los bloques del inodo en orden lógico.
+// fn recibe el número de bloque y su nivel: 0 para bloques de datos, 1..3 para bloques de apuntadores.
+func (sb *SuperBlock) WalkInodeBlocks(disk *Disk, inode *Inode, fn func(blockNum int32, level int) error) error {
+	for i, blockNum := range inode.I_block[:DirectBlocks] {
+		if blockNum == -1 || (blockNum == 0 && i > 0) {
+			continue
+		}
+		if err := fn(blockNum, 0); err != nil {
+			return err
+		}
+	}
+	for i, blockNum := range inode.I_block[DirectBlocks:] {
+		if blockNum <= 0 {
+			continue
+		}
+		if err := sb.walkPointerBlock(disk, blockNum, i+1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkPointerBlock recorre un bloque de apuntadores del nivel indicado y sus hijos
+func (sb *SuperBlock) walkPointerBlock(disk *Disk, ptrNum int32, level int, fn func(blockNum int32, level int) error) error {
+	if err := fn(ptrNum, level); err != nil {
+		return err
+	}
+	pb := sb.NewPointerBlock()
+	if err := pb.Deserialize(disk, sb.BlockOffset(ptrNum)); err != nil {
+		return fmt.Errorf("error al leer bloque de apuntadores %d: %v", ptrNum, err)
+	}
+	for _, child := range pb.P_pointers {
+		if child <= 0 {
+			continue
+		}
+		if level == 1 {
+			if err := fn(child, 0); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := sb.walkPointerBlock(disk, child, level-1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetInodeBlocks devuelve los bloques de datos del inodo en orden lógico
+func (sb *SuperBlock) GetInodeBlocks(disk *Disk, inode *Inode) ([]int32, error) {
+	var blocks []int32
+	err := sb.WalkInodeBlocks(disk, inode, func(blockNum int32, level int) error {
+		if level == 0 {
+			blocks = append(blocks, blockNum)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// SetInodeBlock enlaza blockNum como el bloque lógico index del inodo,
+// creando los bloques de apuntadores intermedios que hagan falta.
+// El inodo se modifica en memoria; el llamador debe serializarlo.
+func (sb *SuperBlock) SetInodeBlock(disk *Disk, inode *Inode, index int, blockNum int32) error {
+	if index < 0 {
+		return fmt.Errorf("índice de bloque inválido: %d", index)
+	}
+	if index < DirectBlocks {
+		inode.I_block[index] = blockNum
+		return nil
+	}
+
+	index -= DirectBlocks
+	span := 1
+	for level := 1; level <= 3; level++ {
+		span *= sb.PointersPerBlock()
+		if index < span {
+			slot := DirectBlocks + level - 1
+			if inode.I_block[slot] <= 0 {
+				ptrNum, err := sb.newPointerBlock(disk)
+				if err != nil {
+					return err
+				}
+				inode.I_block[slot] = ptrNum
+			}
+			return sb.setPointer(disk, inode.I_block[slot], level, index, blockNum)
+		}
+		index -= span
+	}
+	return fmt.Errorf("el inodo no puede direccionar más de %d bloques", sb.MaxInodeBlocks())
+}
+
+// setPointer escribe blockNum en la posición index del árbol de apuntadores que cuelga de ptrNum
+func (sb *SuperBlock) setPointer(disk *Disk, ptrNum int32, level int, index int, blockNum int32) error {
+	pb := sb.NewPointerBlock()
+	offset := sb.BlockOffset(ptrNum)
+	if err := pb.Deserialize(disk, offset); err != nil {
+		return fmt.Errorf("error al leer bloque de apuntadores %d: %v", ptrNum, err)
+	}
+
+	if level == 1 {
+		pb.P_pointers[index] = blockNum
+		return pb.Serialize(disk, offset)
+	}
+
+	// Cantidad de bloques de datos que cubre cada apuntador de este nivel
+	span := 1
+	for i := 1; i < level; i++ {
+		span *= sb.PointersPerBlock()
+	}
+	slot := index / span
+	if pb.P_pointers[slot] <= 0 {
+		child, err := sb.newPointerBlock(disk)
+		if err != nil {
+			return err
+		}
+		pb.P_pointers[slot] = child
+		if err := pb.Serialize(disk, offset); err != nil {
+			return fmt.Errorf("error al escribir bloque de apuntadores %d: %v", ptrNum, err)
+		}
+	}
+	return sb.setPointer(disk, pb.P_pointers[slot], level-1, index%span, blockNum)
+}
+
+// newPointerBlock reserva un bloque libre y lo inicializa como bloque de apuntadores vacío
+func (sb *SuperBlock) newPointerBlock(disk *Disk) (int32, error) {
+	ptrNum, err := sb.AllocBlock(disk)
+	if err != nil {
+		return -1, fmt.Errorf("error al reservar bloque de apuntadores: %v", err)
+	}
+	if err := sb.NewPointerBlock().Serialize(disk, sb.BlockOffset(ptrNum)); err != nil {
+		sb.FreeBlock(disk, ptrNum)
+		return -1, fmt.Errorf("error al escribir bloque de apuntadores %d: %v", ptrNum, err)
+	}
+	return ptrNum, nil
+}
+
+// AllocateInodeBlock reserva un bloque de datos libre y lo enlaza como bloque lógico index del inodo
+func (sb *SuperBlock) AllocateInodeBlock(disk *Disk, inode *Inode, index int) (int32, error) {
+	if index >= sb.MaxInodeBlocks() {
+		return -1, fmt.Errorf("el inodo no puede direccionar más de %d bloques", sb.MaxInodeBlocks())
+	}
+	blockNum, err := sb.AllocBlock(disk)
+	if err != nil {
+		return -1, err
+	}
+	if err := sb.SetInodeBlock(disk, inode, index, blockNum); err != nil {
+		sb.FreeBlock(disk, blockNum)
+		return -1, err
+	}
+	return blockNum, nil
+}
+
+// ReleaseInodeBlocks libera todos los bloques del inodo (datos y apuntadores) y deja I_block vacío
+func (sb *SuperBlock) ReleaseInodeBlocks(disk *Disk, inode *Inode) error {
+	var blocks []int32
+	err := sb.WalkInodeBlocks(disk, inode, func(blockNum int32, level int) error {
+		blocks = append(blocks, blockNum)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sb.FreeBlocks(disk, blocks); err != nil {
+		return fmt.Errorf("error al liberar bloques: %v", err)
+	}
+	for i := range inode.I_block {
+		inode.I_block[i] = -1
+	}
+	return nil
+}
+
+// ReadFile devuelve el contenido de un inodo de archivo, limitado a I_size
+func (sb *SuperBlock) ReadFile(disk *Disk, inode *Inode) ([]byte, error) {
+	blocks, err := sb.GetInodeBlocks(disk, inode)
+	if err != nil {
+		return nil, err
+	}
+
+	content := make([]byte, 0, len(blocks)*int(sb.S_block_size))
+	for _, blockNum := range blocks {
+		fileBlock := sb.NewFileBlock()
+		err := fileBlock.Deserialize(disk, sb.BlockOffset(blockNum))
+		if err != nil {
+			return nil, fmt.Errorf("error al leer bloque %d: %v", blockNum, err)
+		}
+		content = append(content, fileBlock.B_content[:]...)
+	}
+
+	if int(inode.I_size) < len(content) {
+		content = content[:inode.I_size]
+	}
+	return content, nil
+}
+
+// WriteFile reemplaza el contenido del inodo de archivo inodeNum.
+// Reutiliza los bloques que ya tiene, reserva los que falten y libera los que sobren.
+func (sb *SuperBlock) WriteFile(disk *Disk, inodeNum int32, inode *Inode, content []byte) error {
+	// Un archivo vacío conserva un bloque reservado
+	blockSize := int(sb.S_block_size)
+	needed := max((len(content)+blockSize-1)/blockSize, 1)
+	if needed > sb.MaxInodeBlocks() {
+		return fmt.Errorf("contenido demasiado grande, máximo %d bloques", sb.MaxInodeBlocks())
+	}
+
+	blocks, err := sb.GetInodeBlocks(disk, inode)
+	if err != nil {
+		return err
+	}
+
+	if needed < len(blocks) {
+		blocks, err = sb.truncateInodeBlocks(disk, inode, blocks, needed)
+		if err != nil {
+			return err
+		}
+	} else if needed > len(blocks) {
+		extra, err := sb.AllocBlocks(disk, needed-len(blocks))
+		if err != nil {
+			return err
+		}
+		for i, blockNum := range extra {
+			err := sb.SetInodeBlock(disk, inode, len(blocks)+i, blockNum)
+			if err != nil {
+				// Los bloques que no alcanzaron a enlazarse no se pueden liberar recorriendo el inodo
+				sb.FreeBlocks(disk, extra[i:])
+				return fmt.Errorf("error al asignar bloque %d: %v", len(blocks)+i, err)
+			}
+		}
+		blocks = append(blocks, extra...)
+	}
+
+	for i, blockNum := range blocks {
+		fileBlock := sb.NewFileBlock()
+		start := i * blockSize
+		if start < len(content) {
+			end := min(start+blockSize, len(content))
+			copy(fileBlock.B_content[:], content[start:end])
+		}
+		err := fileBlock.Serialize(disk, sb.BlockOffset(blockNum))
+		if err != nil {
+			return fmt.Errorf("error al escribir bloque %d: %v", blockNum, err)
+		}
+	}
+
+	inode.I_size = int32(len(content))
+	inode.I_mtime = float32(time.Now().Unix())
+	err = inode.Serialize(disk, sb.InodeOffset(inodeNum))
+	if err != nil {
+		return fmt.Errorf("error al escribir inodo %d: %v", inodeNum, err)
+	}
+	return nil
+}
+
+// truncateInodeBlocks deja solo los primeros keep bloques de datos del inodo.
+// Los bloques de apuntadores se liberan y se vuelven a crear para los bloques que se conservan.
+func (sb *SuperBlock) truncateInodeBlocks(disk *Disk, inode *Inode, blocks []int32, keep int) ([]int32, error) {
+	released := append([]int32{}, blocks[keep:]...)
+	err := sb.WalkInodeBlocks(disk, inode, func(blockNum int32, level int) error {
+		if level > 0 {
+			released = append(released, blockNum)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := sb.FreeBlocks(disk, released); err != nil {
+		return nil, fmt.Errorf("error al liberar bloques: %v", err)
+	}
+
+	for i := min(keep, DirectBlocks); i < len(inode.I_block); i++ {
+		inode.I_block[i] = -1
+	}
+	for i := DirectBlocks; i < keep; i++ {
+		if err := sb.SetInodeBlock(disk, inode, i, blocks[i]); err != nil {
+			return nil, err
+		}
+	}
+	return blocks[:keep], nil
+}
+
+// ReplaceFile reemplaza el contenido del inodo de archivo inodeNum sin tocar sus bloques actuales:
+// escribe el contenido en bloques nuevos, apunta el inodo a ellos con una sola escritura y
+// después libera los anteriores. Si algo falla antes de escribir el inodo, el archivo
+// conserva su contenido anterior y los bloques nuevos se liberan.
+func (sb *SuperBlock) ReplaceFile(disk *Disk, inodeNum int32, inode *Inode, content []byte) error {
+	// Un archivo vacío conserva un bloque reservado
+	blockSize := int(sb.S_block_size)
+	needed := max((len(content)+blockSize-1)/blockSize, 1)
+	if needed > sb.MaxInodeBlocks() {
+		return fmt.Errorf("contenido demasiado grande, máximo %d bloques", sb.MaxInodeBlocks())
+	}
+
+	var old []int32
+	err := sb.WalkInodeBlocks(disk, inode, func(blockNum int32, level int) error {
+		old = append(old, blockNum)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	blocks, err := sb.AllocBlocks(disk, needed)
+	if err != nil {
+		return err
+	}
+	updated := *inode
+	for i := range updated.I_block {
+		updated.I_block[i] = -1
+	}
+	if err := sb.writeReplacement(disk, &updated, blocks, content); err != nil {
+		// Los bloques de apuntadores ya enlazados cuelgan de updated; los de datos, de blocks
+		var fresh []int32
+		sb.WalkInodeBlocks(disk, &updated, func(blockNum int32, level int) error {
+			if level > 0 {
+				fresh = append(fresh, blockNum)
+			}
+			return nil
+		})
+		sb.FreeBlocks(disk, append(fresh, blocks...))
+		return err
+	}
+
+	updated.I_size = int32(len(content))
+	updated.I_mtime = float32(time.Now().Unix())
+	if err := updated.Serialize(disk, sb.InodeOffset(inodeNum)); err != nil {
+		return fmt.Errorf("error al escribir inodo %d: %v", inodeNum, err)
+	}
+	*inode = updated
+
+	if err := sb.FreeBlocks(disk, old); err != nil {
+		return fmt.Errorf("error al liberar bloques: %v", err)
+	}
+	return nil
+}
+
+// writeReplacement escribe content en blocks y los enlaza en inode, que todavía no está en el disco
+func (sb *SuperBlock) writeReplacement(disk *Disk, inode *Inode, blocks []int32, content []byte) error {
+	blockSize := int(sb.S_block_size)
+	for i, blockNum := range blocks {
+		fileBlock := sb.NewFileBlock()
+		start := i * blockSize
+		if start < len(content) {
+			end := min(start+blockSize, len(content))
+			copy(fileBlock.B_content[:], content[start:end])
+		}
+		if err := fileBlock.Serialize(disk, sb.BlockOffset(blockNum)); err != nil {
+			return fmt.Errorf("error al escribir bloque %d: %v", blockNum, err)
+		}
+		if err := sb.SetInodeBlock(disk, inode, i, blockNum); err != nil {
+			return fmt.Errorf("error al asignar bloque %d: %v", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package structures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Information struct {
+	I_operation [10]byte
+	I_path      [64]byte
+	I_content   [128]byte // Alcanza para el hash de la contraseña de mkusr
+	I_date      float32
+	// Total: 206 bytes
+}
+
+type Journal struct {
+	J_count   int32
+	J_uid     int32 // Usuario que ejecutó la operación
+	J_gid     int32 // Grupo con el que la ejecutó
+	J_content Information
+	// Total: 218 bytes
+}
+
+/*
+En EXT3 el área del journal va justo después del superbloque y tiene una entrada por inodo:
+
+	| SuperBlock | Journal x n | Bitmap inodos | Bitmap bloques | Inodos | Bloques |
+
+Las entradas se escriben en orden; la primera con J_count en 0 marca el final del journal.
+Cada entrada guarda el UID y el GID de la sesión que ejecutó la operación, para que recovery
+la repita como ese usuario.
+*/
+
+// Serialize escribe la estructura Journal en el disco en la posición especificada
+func (journal *Journal) Serialize(disk *Disk, offset int64) error {
+	return disk.WriteStruct(offset, journal)
+}
+
+// Deserialize lee la estructura Journal desde el disco en la posición especificada
+func (journal *Journal) Deserialize(disk *Disk, offset int64) error {
+	return disk.ReadStruct(offset, journal)
+}
+
+// Print imprime los atributos de la entrada del journal
+func (journal *Journal) Print() {
+	date := time.Unix(int64(journal.J_content.I_date), 0)
+	fmt.Printf("Count: %d\n", journal.J_count)
+	fmt.Printf("Operation: %s\n", journal.Operation())
+	fmt.Printf("Path: %s\n", journal.Path())
+	fmt.Printf("Content: %s\n", journal.Content())
+	fmt.Printf("Date: %s\n", date.Format(time.RFC3339))
+}
+
+// Operation devuelve la operación registrada sin los caracteres nulos
+func (journal *Journal) Operation() string {
+	return strings.TrimRight(string(journal.J_content.I_operation[:]), "\x00")
+}
+
+// Path devuelve la ruta registrada sin los caracteres nulos
+func (journal *Journal) Path() string {
+	return strings.TrimRight(string(journal.J_content.I_path[:]), "\x00")
+}
+
+// Content devuelve el contenido registrado sin los caracteres nulos
+func (journal *Journal) Content() string {
+	return strings.TrimRight(string(journal.J_content.I_content[:]), "\x00")
+}
+
+// IsExt3 indica si el sistema de archivos tiene journal
+func (sb *SuperBlock) IsExt3() bool {
+	return sb.FsType() == 3
+}
+
+// JournalStart devuelve la posición en disco de la primera entrada del journal
+func (sb *SuperBlock) JournalStart() int64 {
+	return int64(sb.S_bm_inode_start) - int64(sb.S_inodes_count)*int64(binary.Size(Journal{}))
+}
+
+// CreateJournal deja vacía el área del journal
+func (sb *SuperBlock) CreateJournal(disk *Disk) error {
+	if !sb.IsExt3() {
+		return nil
+	}
+	return disk.Zero(sb.JournalStart(), int64(sb.S_bm_inode_start)-sb.JournalStart())
+}
+
+// ReadJournal devuelve las entradas registradas en el journal, en orden
+func (sb *SuperBlock) ReadJournal(disk *Disk) ([]Journal, error) {
+	if !sb.IsExt3() {
+		return nil, errors.New("el sistema de archivos no es EXT3, no tiene journal")
+	}
+
+	// Leer toda el área del journal de una vez
+	area := make([]byte, int64(sb.S_bm_inode_start)-sb.JournalStart())
+	err := disk.ReadAt(area, sb.JournalStart())
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el journal: %v", err)
+	}
+
+	var entries []Journal
+	reader := bytes.NewReader(area)
+	for range sb.S_inodes_count {
+		var entry Journal
+		err := binary.Read(reader, binary.LittleEndian, &entry)
+		if err != nil {
+			return nil, fmt.Errorf("error al leer el journal: %v", err)
+		}
+		if entry.J_count == 0 {
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// CheckJournalSpace verifica que queda lugar en el journal para una entrada más
+func (sb *SuperBlock) CheckJournalSpace(disk *Disk) error {
+	entries, err := sb.ReadJournal(disk)
+	if err != nil {
+		return err
+	}
+	if int32(len(entries)) >= sb.S_inodes_count {
+		return errors.New("el journal está lleno")
+	}
+	return nil
+}
+
+// CheckJournalEntry verifica que la operación, la ruta y el contenido caben en una entrada del journal.
+// Una entrada recortada se repetiría mal en recovery, así que no se registra.
+func (sb *SuperBlock) CheckJournalEntry(operation, itemPath, content string) error {
+	var info Information
+	switch {
+	case len(operation) > len(info.I_operation):
+		return fmt.Errorf("la operación %s no cabe en el journal (máximo %d bytes)", operation, len(info.I_operation))
+	case len(itemPath) > len(info.I_path):
+		return fmt.Errorf("la ruta %s no cabe en el journal (máximo %d bytes)", itemPath, len(info.I_path))
+	case len(content) > len(info.I_content):
+		// El contenido puede llevar el hash de una contraseña, así que no se incluye en el mensaje
+		return fmt.Errorf("los parámetros no caben en el journal (%d bytes, máximo %d)", len(content), len(info.I_content))
+	}
+	return nil
+}
+
+// AppendJournal agrega una entrada al final del journal con la operación que ejecutó el usuario uid
+// con el grupo gid. En EXT2 no hace nada.
+func (sb *SuperBlock) AppendJournal(disk *Disk, operation, itemPath, content string, uid, gid int32) error {
+	if !sb.IsExt3() {
+		return nil
+	}
+
+	entries, err := sb.ReadJournal(disk)
+	if err != nil {
+		return err
+	}
+	if int32(len(entries)) >= sb.S_inodes_count {
+		return errors.New("el journal está lleno")
+	}
+	if err := sb.CheckJournalEntry(operation, itemPath, content); err != nil {
+		return err
+	}
+
+	entry := &Journal{J_count: int32(len(entries)) + 1, J_uid: uid, J_gid: gid}
+	copy(entry.J_content.I_operation[:], operation)
+	copy(entry.J_content.I_path[:], itemPath)
+	copy(entry.J_content.I_content[:], content)
+	entry.J_content.I_date = float32(time.Now().Unix())
+
+	offset := sb.JournalStart() + int64(len(entries))*int64(binary.Size(Journal{}))
+	return entry.Serialize(disk, offset)
+}
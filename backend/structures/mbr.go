@@ -1,11 +1,9 @@
 package structures
 
 import (
-	"bytes"           // Paquete para manipulación de buffers
 	"encoding/binary" // Paquete para codificación y decodificación de datos binarios
 	"errors"
 	"fmt" // Paquete para formateo de E/S
-	"os"  // Paquete para funciones del sistema operativo
 	"strings"
 	"time"
 )
@@ -18,71 +16,61 @@ type MBR struct {
 	Mbr_partitions     [4]Partition // Particiones del MBR
 }
 
-// SerializeMBR escribe la estructura MBR al inicio de un archivo binario
+// SerializeMBR escribe la estructura MBR al inicio de la imagen de disco
 func (mbr *MBR) Serialize(path string) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	disk, err := OpenImage(path)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	// Serializar la estructura MBR directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, mbr)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	defer disk.Close()
+	return disk.WriteStructThrough(0, mbr)
 }
 
-// DeserializeMBR lee la estructura MBR desde el inicio de un archivo binario
+// DeserializeMBR lee la estructura MBR desde el inicio de la imagen de disco
 func (mbr *MBR) Deserialize(path string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Obtener el tamaño de la estructura MBR
-	mbrSize := binary.Size(mbr)
-	if mbrSize <= 0 {
-		return fmt.Errorf("invalid MBR size: %d", mbrSize)
-	}
-
-	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura MBR
-	buffer := make([]byte, mbrSize)
-	_, err = file.Read(buffer)
+	disk, err := OpenImage(path)
 	if err != nil {
 		return err
 	}
+	defer disk.Close()
+	return disk.ReadStruct(0, mbr)
+}
 
-	// Deserializar los bytes leídos en la estructura MBR
-	reader := bytes.NewReader(buffer)
-	err = binary.Read(reader, binary.LittleEndian, mbr)
-	if err != nil {
-		return err
+// UsedSegments devuelve los segmentos del disco ocupados por particiones primarias y extendidas
+func (mbr *MBR) UsedSegments() []Segment {
+	var used []Segment
+	for _, p := range mbr.Mbr_partitions {
+		if p.Part_status[0] != 'N' && p.Part_size > 0 {
+			used = append(used, Segment{Start: p.Part_start, Size: p.Part_size})
+		}
 	}
-
-	return nil
+	return used
 }
 
-// Método para obtener la primera partición disponible
-func (mbr *MBR) GetFirstAvailablePartition() (*Partition, int, int) {
-	// Calcular el offset para el start de la partición
-	offset := binary.Size(mbr) // Tamaño del MBR en bytes
+// FreeSegments devuelve los huecos libres del disco después del MBR, ordenados por inicio
+func (mbr *MBR) FreeSegments() []Segment {
+	return FreeSegments(int32(binary.Size(mbr)), mbr.Mbr_size, mbr.UsedSegments())
+}
 
-	// Recorrer las particiones del MBR
-	for i := 0; i < len(mbr.Mbr_partitions); i++ {
-		// Si el start de la partición es -1, entonces está disponible
-		if mbr.Mbr_partitions[i].Part_start == -1 {
-			// Devolver la partición, el offset y el índice
-			return &mbr.Mbr_partitions[i], offset, i
-		} else {
-			// Calcular el nuevo offset para la siguiente partición, es decir, sumar el tamaño de la partición
-			offset += int(mbr.Mbr_partitions[i].Part_size)
+// Método para obtener una entrada libre del MBR y el byte de inicio, elegido según el ajuste
+// del disco, de un hueco de al menos size bytes
+func (mbr *MBR) GetAvailablePartition(size int32) (*Partition, int, int, error) {
+	idx := -1
+	for i := range mbr.Mbr_partitions {
+		if mbr.Mbr_partitions[i].Part_status[0] == 'N' {
+			idx = i
+			break
 		}
 	}
-	return nil, -1, -1
+	if idx == -1 {
+		return nil, -1, -1, errors.New("no hay particiones disponibles en el MBR")
+	}
+
+	gap, ok := SelectFit(mbr.FreeSegments(), size, mbr.Mbr_disk_fit[0])
+	if !ok {
+		return nil, -1, -1, errors.New("no hay espacio suficiente en el disco")
+	}
+	return &mbr.Mbr_partitions[idx], int(gap.Start), idx, nil
 }
 
 // Método para obtener una partición por nombre
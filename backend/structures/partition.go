@@ -61,6 +61,30 @@ func (p *Partition) MountPartition(correlative int, id string) error {
 	return nil
 }
 
+// Desmontar la partición, devolviéndola al estado de creada
+func (p *Partition) UnmountPartition() {
+	// El valor '0' indica que la partición está creada pero no montada
+	p.Part_status[0] = '0'
+
+	// Limpiar correlativo e ID
+	p.Part_correlative = 0
+	p.Part_id = [4]byte{}
+}
+
+// Eliminar la partición, dejando la entrada como disponible
+func (p *Partition) DeletePartition() {
+	*p = Partition{
+		Part_status:      [1]byte{'N'},
+		Part_type:        [1]byte{'N'},
+		Part_fit:         [1]byte{'N'},
+		Part_start:       -1,
+		Part_size:        -1,
+		Part_name:        [16]byte{'N'},
+		Part_correlative: -1,
+		Part_id:          [4]byte{'N'},
+	}
+}
+
 // Imprimir los valores de la partición
 func (p *Partition) PrintPartition() {
 	fmt.Printf("Part_status: %c\n", p.Part_status[0])
@@ -1,6 +1,43 @@
 package structures
 
+import (
+	"errors"
+	"fmt"
+)
+
 type PointerBlock struct {
-	P_pointers [16]int32 // 16 * 4 = 64 bytes
-	// Total: 64 bytes
+	P_pointers []int32 // S_block_size / 4 apuntadores
+}
+
+// PointersPerBlock devuelve cuántos apuntadores caben en un bloque de apuntadores
+func (sb *SuperBlock) PointersPerBlock() int {
+	return int(sb.S_block_size) / 4
+}
+
+// NewPointerBlock crea un bloque de apuntadores con todos los apuntadores libres (-1)
+func (sb *SuperBlock) NewPointerBlock() *PointerBlock {
+	pb := &PointerBlock{P_pointers: make([]int32, sb.PointersPerBlock())}
+	for i := range pb.P_pointers {
+		pb.P_pointers[i] = -1
+	}
+	return pb
+}
+
+// Serialize escribe la estructura PointerBlock en el disco en la posición especificada
+func (pb *PointerBlock) Serialize(disk *Disk, offset int64) error {
+	return disk.WriteStruct(offset, pb.P_pointers)
+}
+
+// Deserialize lee la estructura PointerBlock desde el disco en la posición especificada.
+// El bloque debe crearse con NewPointerBlock para saber cuántos apuntadores leer.
+func (pb *PointerBlock) Deserialize(disk *Disk, offset int64) error {
+	if len(pb.P_pointers) == 0 {
+		return errors.New("bloque de apuntadores sin tamaño, use NewPointerBlock")
+	}
+	return disk.ReadStruct(offset, pb.P_pointers)
+}
+
+// Print imprime los apuntadores del bloque
+func (pb *PointerBlock) Print() {
+	fmt.Printf("P_pointers: %v\n", pb.P_pointers)
 }
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Hash de contraseñas.
+
+Las contraseñas se guardan como pbkdf2-sha256$iteraciones$sal$hash, con la sal y el
+hash en base64 sin relleno. Las iteraciones quedan en el propio hash, así que se
+pueden aumentar sin invalidar las contraseñas existentes.
+*/
+
+const (
+	passwordScheme     = "pbkdf2-sha256"
+	passwordIterations = 100000
+	passwordSaltSize   = 16
+	passwordKeySize    = 32
+)
+
+// HashPassword devuelve el hash con sal aleatoria de la contraseña
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("error al generar la sal: %v", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeySize)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s$%d$%s$%s", passwordScheme, passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// IsPasswordHash indica si stored tiene el formato de HashPassword
+func IsPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, passwordScheme+"$")
+}
+
+// CheckPassword indica si password corresponde al hash stored
+func CheckPassword(stored, password string) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
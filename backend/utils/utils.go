@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // ConvertToBytes convierte un tamaño y una unidad a bytes
@@ -38,8 +40,14 @@ var pathToPartitionCount = make(map[string]int)
 // Índice para la siguiente letra disponible en el abecedario
 var nextLetterIndex = 0
 
+// lettersMu protege pathToLetter, pathToPartitionCount y nextLetterIndex
+var lettersMu sync.Mutex
+
 // GetLetter obtiene la letra asignada a un path y el siguiente índice de partición
 func GetLetterAndPartitionCorrelative(path string) (string, int, error) {
+	lettersMu.Lock()
+	defer lettersMu.Unlock()
+
 	// Asignar una letra al path si no tiene una asignada
 	if _, exists := pathToLetter[path]; !exists {
 		if nextLetterIndex < len(alphabet) {
@@ -59,6 +67,76 @@ func GetLetterAndPartitionCorrelative(path string) (string, int, error) {
 	return pathToLetter[path], nextIndex, nil
 }
 
+// LetterState es la asignación de letras y correlativos por disco, usada para persistirla
+type LetterState struct {
+	Letters      map[string]string `json:"letters"`      // Letra asignada a cada path
+	Correlatives map[string]int    `json:"correlatives"` // Último correlativo usado en cada path
+	NextLetter   int               `json:"next_letter"`  // Índice de la siguiente letra disponible
+}
+
+// GetLetterState devuelve una copia de la asignación actual de letras y correlativos
+func GetLetterState() LetterState {
+	lettersMu.Lock()
+	defer lettersMu.Unlock()
+
+	state := LetterState{
+		Letters:      make(map[string]string, len(pathToLetter)),
+		Correlatives: make(map[string]int, len(pathToPartitionCount)),
+		NextLetter:   nextLetterIndex,
+	}
+	for path, letter := range pathToLetter {
+		state.Letters[path] = letter
+	}
+	for path, count := range pathToPartitionCount {
+		state.Correlatives[path] = count
+	}
+	return state
+}
+
+// SetLetterState reemplaza la asignación de letras y correlativos
+func SetLetterState(state LetterState) {
+	lettersMu.Lock()
+	defer lettersMu.Unlock()
+
+	pathToLetter = make(map[string]string, len(state.Letters))
+	pathToPartitionCount = make(map[string]int, len(state.Correlatives))
+	for path, letter := range state.Letters {
+		pathToLetter[path] = letter
+	}
+	for path, count := range state.Correlatives {
+		pathToPartitionCount[path] = count
+	}
+	nextLetterIndex = state.NextLetter
+}
+
+// ReserveLetterAndPartitionCorrelative registra que letter y correlative ya están en uso en path.
+// Devuelve false si la letra pertenece a otro disco.
+func ReserveLetterAndPartitionCorrelative(path string, letter string, correlative int) bool {
+	lettersMu.Lock()
+	defer lettersMu.Unlock()
+
+	if assigned, exists := pathToLetter[path]; exists {
+		if assigned != letter {
+			return false
+		}
+	} else {
+		index := slices.Index(alphabet, letter)
+		if index == -1 {
+			return false
+		}
+		for _, assigned := range pathToLetter {
+			if assigned == letter {
+				return false
+			}
+		}
+		pathToLetter[path] = letter
+		nextLetterIndex = max(nextLetterIndex, index+1)
+	}
+
+	pathToPartitionCount[path] = max(pathToPartitionCount[path], correlative)
+	return true
+}
+
 // createParentDirs crea las carpetas padre si no existen
 func CreateParentDirs(path string) error {
 	dir := filepath.Dir(path)
@@ -139,3 +217,63 @@ func StringToInt(s string) (int, error) {
 	}
 	return num, nil
 }
+
+// MatchWildcard indica si name coincide con pattern, donde ? representa un carácter y * cualquier secuencia
+func MatchWildcard(pattern, name string) bool {
+	p, n := []rune(pattern), []rune(name)
+	pi, ni := 0, 0
+	star, mark := -1, 0
+	for ni < len(n) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == n[ni]):
+			pi++
+			ni++
+		case pi < len(p) && p[pi] == '*':
+			// Recordar el * para retroceder si lo que sigue no coincide
+			star, mark = pi, ni
+			pi++
+		case star != -1:
+			pi = star + 1
+			mark++
+			ni = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// SplitCommand divide la entrada respetando cadenas entre comillas
+func SplitCommand(input string) []string {
+	var tokens []string
+	var currentToken strings.Builder
+	inQuotes := false
+
+	for i := 0; i < len(input); i++ {
+		char := input[i]
+
+		switch char {
+		case '"':
+			inQuotes = !inQuotes
+			// No agregamos las comillas al token, pero las respetamos en el proceso
+		case ' ':
+			if inQuotes {
+				currentToken.WriteByte(char)
+			} else if currentToken.Len() > 0 {
+				tokens = append(tokens, currentToken.String())
+				currentToken.Reset()
+			}
+		default:
+			currentToken.WriteByte(char)
+		}
+	}
+
+	if currentToken.Len() > 0 {
+		tokens = append(tokens, currentToken.String())
+	}
+
+	return tokens
+}
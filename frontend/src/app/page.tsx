@@ -4,7 +4,7 @@ import { useState } from "react";
 import InputTerminal from "@/components/InputTerminal";
 import OutputTerminal from "@/components/OutputTerminal";
 import FileUpload from "@/components/FileUpload";
-import { executeCommands } from "@/services/api";
+import { executeCommands, saveSessionToken, sessionHeaders } from "@/services/api";
 
 export default function Home() {
   const [input, setInput] = useState("");
@@ -21,9 +21,10 @@ export default function Home() {
     try {
       const response = await fetch("http://localhost:3001/execute", {
         method: "POST",
-        headers: { "Content-Type": "application/json" },
+        headers: { "Content-Type": "application/json", ...sessionHeaders() },
         body: JSON.stringify({ command: input }),
       });
+      saveSessionToken(response);
       const data = await response.json();
       setOutput(data.output);
     } catch (error) {
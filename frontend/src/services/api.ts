@@ -1,14 +1,32 @@
 const API_URL = "http://localhost:3001";
 
+// Token de sesión del servidor; se guarda por pestaña para que cada una tenga su propio login
+const SESSION_HEADER = "X-Session-Token";
+const SESSION_KEY = "mia_session";
+
+export const sessionHeaders = (): Record<string, string> => {
+  const token = sessionStorage.getItem(SESSION_KEY);
+  return token ? { [SESSION_HEADER]: token } : {};
+};
+
+export const saveSessionToken = (response: Response) => {
+  const token = response.headers.get(SESSION_HEADER);
+  if (token) {
+    sessionStorage.setItem(SESSION_KEY, token);
+  }
+};
+
 export const executeCommands = async (command: string): Promise<string> => {
   try {
     const response = await fetch(`${API_URL}/execute`, {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
+        ...sessionHeaders(),
       },
       body: JSON.stringify({ command }),
     });
+    saveSessionToken(response);
 
     if (!response.ok) {
       throw new Error("Error en la respuesta del servidor");